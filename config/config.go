@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -9,11 +10,17 @@ import (
 type (
 	// Config -.
 	Config struct {
-		App  `yaml:"app"`
-		HTTP `yaml:"http"`
-		Log  `yaml:"logger"`
-		PG   `yaml:"postgres"`
-		JWT  `yaml:"jwt"`
+		App       `yaml:"app"`
+		HTTP      `yaml:"http"`
+		Log       `yaml:"logger"`
+		PG        `yaml:"postgres"`
+		JWT       `yaml:"jwt"`
+		Scheduler `yaml:"scheduler"`
+		Auction   `yaml:"auction"`
+		Search    `yaml:"search"`
+		Storage   `yaml:"storage"`
+		Trash     `yaml:"trash"`
+		Events    `yaml:"events"`
 	}
 
 	// App -.
@@ -25,6 +32,9 @@ type (
 	// HTTP -.
 	HTTP struct {
 		Port string `env-required:"true" yaml:"port" env:"HTTP_PORT"`
+		// AllowedOrigins are the origins of the web pages, besides the API's own
+		// one, allowed to open the WebSocket bidding channel.
+		AllowedOrigins []string `yaml:"allowed_origins" env:"HTTP_ALLOWED_ORIGINS"`
 	}
 
 	// Log -.
@@ -42,6 +52,61 @@ type (
 	JWT struct {
 		Secret string `env-required:"true" yaml:"secret" env:"JWT_SECRET"`
 	}
+
+	// Scheduler -.
+	Scheduler struct {
+		Interval time.Duration `env-required:"true" yaml:"interval" env:"SCHEDULER_INTERVAL"`
+	}
+
+	// Auction -.
+	Auction struct {
+		// BuyNowShare is the share of the buy-now price which, once reached by
+		// the bids, takes the buy-now option off the lot.
+		BuyNowShare float64 `env-required:"true" yaml:"buy_now_share" env:"AUCTION_BUY_NOW_SHARE"`
+		// Commission is the share of the end price of a sold lot kept by the
+		// platform.
+		Commission float64 `env-required:"true" yaml:"commission" env:"AUCTION_COMMISSION"`
+		// PaymentDeadline is the time the winner has to pay for a sold lot in
+		// after the end of the auction, the lot is offered to the next bidder
+		// then. OfferExpiry is the time the next bidder has to accept it in.
+		PaymentDeadline time.Duration `env-required:"true" yaml:"payment_deadline" env:"AUCTION_PAYMENT_DEADLINE"`
+		OfferExpiry     time.Duration `env-required:"true" yaml:"offer_expiry"     env:"AUCTION_OFFER_EXPIRY"`
+	}
+
+	// Search -.
+	Search struct {
+		// Language is the text search configuration (english, german, ...) the
+		// title and description of a new lot are indexed in. Every lot keeps the
+		// language it was indexed in and is searched in it.
+		Language string `env-required:"true" yaml:"language" env:"SEARCH_LANGUAGE"`
+	}
+
+	// Storage -.
+	Storage struct {
+		// Dir is the directory the uploaded files are kept in, they are served
+		// under BaseURL.
+		Dir     string `env-required:"true" yaml:"dir"      env:"STORAGE_DIR"`
+		BaseURL string `env-required:"true" yaml:"base_url" env:"STORAGE_BASE_URL"`
+		// MaxUploadSize is the largest file in bytes which can be attached to a
+		// lot, ThumbnailSize the longer side in pixels of the image thumbnails.
+		MaxUploadSize int64 `env-required:"true" yaml:"max_upload_size" env:"STORAGE_MAX_UPLOAD_SIZE"`
+		ThumbnailSize int   `env-required:"true" yaml:"thumbnail_size"  env:"STORAGE_THUMBNAIL_SIZE"`
+	}
+
+	// Trash -.
+	Trash struct {
+		// Retention is the time a deleted lot is kept in the trash for, it can
+		// be restored until then and is purged after.
+		Retention time.Duration `env-required:"true" yaml:"retention" env:"TRASH_RETENTION"`
+	}
+
+	// Events -.
+	Events struct {
+		// HistorySize is the number of the last events kept for every lot, a
+		// client coming back with Last-Event-ID gets the ones it has missed.
+		HistorySize int           `env-required:"true" yaml:"history_size" env:"EVENTS_HISTORY_SIZE"`
+		Heartbeat   time.Duration `env-required:"true" yaml:"heartbeat"    env:"EVENTS_HEARTBEAT"`
+	}
 )
 
 // NewConfig returns app config.
@@ -58,5 +123,23 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	err = cfg.Auction.validate()
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
 	return cfg, nil
 }
+
+// validate checks the shares of the auction settings are in range.
+func (a Auction) validate() error {
+	if a.BuyNowShare <= 0 || a.BuyNowShare > 1 {
+		return fmt.Errorf("auction.buy_now_share must be in (0, 1], got %v", a.BuyNowShare)
+	}
+
+	if a.Commission < 0 || a.Commission >= 1 {
+		return fmt.Errorf("auction.commission must be in [0, 1), got %v", a.Commission)
+	}
+
+	return nil
+}
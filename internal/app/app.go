@@ -9,7 +9,9 @@ import (
 
 	"github.com/ElOtro/auction-go/config"
 	v1 "github.com/ElOtro/auction-go/internal/controller/http/v1"
+	"github.com/ElOtro/auction-go/internal/infrastructure/broker"
 	repo "github.com/ElOtro/auction-go/internal/infrastructure/repo/postgres"
+	"github.com/ElOtro/auction-go/internal/infrastructure/storage"
 	"github.com/ElOtro/auction-go/internal/usecase"
 	"github.com/ElOtro/auction-go/pkg/httpserver"
 	"github.com/ElOtro/auction-go/pkg/logger"
@@ -30,14 +32,40 @@ func Run(cfg *config.Config) {
 	// pg models
 	pgModels := repo.NewRepo(pg)
 
+	// Lot events: they are published with NOTIFY and every instance passes them
+	// on to the subscribers connected to it.
+	events := broker.New(cfg.Events.HistorySize)
+
+	listener := postgres.NewListener(cfg.PG.URL, repo.EventChannel, func(payload string) {
+		event, err := repo.DecodeEvent(payload)
+		if err != nil {
+			l.Error(fmt.Errorf("app - Run - repo.DecodeEvent: %w", err))
+			return
+		}
+		events.Publish(event)
+	}, postgres.OnError(func(err error) {
+		l.Error(fmt.Errorf("app - Run - listener: %w", err))
+	}))
+	listener.Start()
+
+	// Uploaded files
+	files, err := storage.NewLocal(cfg.Storage.Dir, cfg.Storage.BaseURL)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - storage.NewLocal: %w", err))
+	}
+
 	// use cases
-	useCases := usecase.NewUseCases(&pgModels)
+	useCases := usecase.NewUseCases(&pgModels, files, repo.NewEventRepo(pg, l), events, cfg.Auction, cfg.Search, cfg.Storage, cfg.Trash)
+
+	// Scheduler
+	scheduler := usecase.NewLotScheduler(&useCases.Lot, l, cfg.Scheduler.Interval)
+	scheduler.Start()
 
 	// controllers
-	controllers := v1.NewControllers(&useCases, cfg.JWT.Secret)
+	controllers := v1.NewControllers(&useCases, cfg.JWT.Secret, cfg.Events.Heartbeat, cfg.Storage.MaxUploadSize, cfg.HTTP.AllowedOrigins)
 
 	// HTTP Server
-	h := v1.NewHandlers(controllers)
+	h := v1.NewHandlers(controllers, files, cfg.Storage.BaseURL)
 	httpServer := httpserver.New(h.Routes(), httpserver.Port(cfg.HTTP.Port))
 
 	// Waiting signal
@@ -52,8 +80,15 @@ func Run(cfg *config.Config) {
 	}
 
 	// Shutdown
+	// The event streams never end on their own, they are closed first so that
+	// the server doesn't wait for them.
+	events.Close()
+
 	err = httpServer.Shutdown()
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
+
+	scheduler.Stop()
+	listener.Stop()
 }
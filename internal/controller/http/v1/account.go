@@ -0,0 +1,193 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+type AccountUseCase interface {
+	Show(userID int64) (*entity.Account, error)
+	Entries(userID int64) ([]*entity.LedgerEntry, error)
+	Deposit(userID int64, transfer *entity.Transfer, adminID int64) (*entity.LedgerTransaction, error)
+	Withdraw(userID int64, transfer *entity.Transfer, adminID int64) (*entity.LedgerTransaction, error)
+}
+
+type AccountController struct {
+	uc AccountUseCase
+}
+
+func NewAccountController(uc AccountUseCase) *AccountController {
+	return &AccountController{uc: uc}
+}
+
+type showAccountResponse struct {
+	Account *entity.Account `json:"account"`
+}
+
+type listLedgerEntryResponse struct {
+	Entries []*entity.LedgerEntry `json:"entries"`
+}
+
+type transferRequest struct {
+	Transfer *entity.Transfer `json:"transfer"`
+}
+
+type ledgerTransactionResponse struct {
+	Transaction *entity.LedgerTransaction `json:"transaction"`
+}
+
+// Get          godoc
+// @Summary     Show account
+// @Description show the account balance of the current user
+// @ID          account
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} showAccountResponse
+// @Failure     404
+// @Failure     500
+// @Router      /account [get]
+func (c *AccountController) Show(w http.ResponseWriter, r *http.Request) {
+	user := contextGetUser(r)
+
+	account, err := c.uc.Show(user.ID)
+	if err != nil {
+		accountErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, showAccountResponse{account}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Show account transactions
+// @Description show the ledger entries of the current user's account, the latest first
+// @ID          account-transactions
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} listLedgerEntryResponse
+// @Failure     404
+// @Failure     500
+// @Router      /account/transactions [get]
+func (c *AccountController) Entries(w http.ResponseWriter, r *http.Request) {
+	user := contextGetUser(r)
+
+	entries, err := c.uc.Entries(user.ID)
+	if err != nil {
+		accountErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, listLedgerEntryResponse{entries}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Deposit
+// @Description pay money into the account of the user, admin only
+// @ID          create-deposit
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int             true "User ID"                  Format(int64)
+// @Param       transfer      body     transferRequest true "Deposit"
+// @Param       Authorization header   string          true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     201           {object} ledgerTransactionResponse
+// @Failure     400
+// @Failure     403
+// @Failure     404
+// @Failure     422
+// @Failure     500
+// @Router      /users/{id}/deposits [post]
+func (c *AccountController) Deposit(w http.ResponseWriter, r *http.Request) {
+	c.transfer(w, r, c.uc.Deposit)
+}
+
+// Get          godoc
+// @Summary     Withdrawal
+// @Description pay money out of the account of the user, admin only
+// @ID          create-withdrawal
+// @Tags        accounts
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int             true "User ID"                  Format(int64)
+// @Param       transfer      body     transferRequest true "Withdrawal"
+// @Param       Authorization header   string          true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     201           {object} ledgerTransactionResponse
+// @Failure     400
+// @Failure     403
+// @Failure     404
+// @Failure     409
+// @Failure     422
+// @Failure     500
+// @Router      /users/{id}/withdrawals [post]
+func (c *AccountController) Withdraw(w http.ResponseWriter, r *http.Request) {
+	c.transfer(w, r, c.uc.Withdraw)
+}
+
+// transfer reads and checks the transfer request and makes it with the post
+// function.
+func (c *AccountController) transfer(w http.ResponseWriter, r *http.Request, post func(userID int64, transfer *entity.Transfer, adminID int64) (*entity.LedgerTransaction, error)) {
+	userID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	admin := contextGetUser(r)
+
+	var input transferRequest
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Transfer == nil {
+		failedValidationResponse(w, r, map[string]string{"transfer": "must be provided"})
+		return
+	}
+
+	v := validator.New()
+
+	if entity.ValidateTransfer(v, input.Transfer); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	t, err := post(userID, input.Transfer, admin.ID)
+	if err != nil {
+		accountErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusCreated, ledgerTransactionResponse{t}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// accountErrorResponse sends the response matching an error returned for an
+// account.
+func accountErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, entity.ErrRecordNotFound):
+		notFoundResponse(w, r)
+	case errors.Is(err, entity.ErrInsufficientFunds):
+		insufficientFundsResponse(w, r)
+	default:
+		serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+// multipartOverhead is the room left in an upload request for the multipart
+// headers and boundaries besides the file itself.
+const multipartOverhead = 1 << 20
+
+type AttachmentUseCase interface {
+	List(lotID int64) ([]*entity.Attachment, error)
+	Create(attachment *entity.Attachment, file io.ReadSeeker, user *entity.User) error
+	Delete(lotID, id int64, user *entity.User) error
+}
+
+type AttachmentController struct {
+	uc            AttachmentUseCase
+	maxUploadSize int64
+}
+
+func NewAttachmentController(uc AttachmentUseCase, maxUploadSize int64) *AttachmentController {
+	return &AttachmentController{uc: uc, maxUploadSize: maxUploadSize}
+}
+
+type listAttachmentResponse struct {
+	Attachments []*entity.Attachment `json:"attachments"`
+}
+
+type attachmentResponse struct {
+	Attachment *entity.Attachment `json:"attachment"`
+}
+
+// Get          godoc
+// @Summary     Show attachment list
+// @Description show the photos and documents attached to the lot
+// @ID          attachments
+// @Tags        attachments
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} listAttachmentResponse
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/attachments [get]
+func (c *AttachmentController) List(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	attachments, err := c.uc.List(lotID)
+	if err != nil {
+		attachmentErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, listAttachmentResponse{attachments}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Create attachment
+// @Description attach a JPEG, PNG or GIF photo or a PDF document to the lot, a thumbnail is made of a photo
+// @ID          create-attachment
+// @Tags        attachments
+// @Accept      mpfd
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       file          formData file   true "File"
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     201           {object} attachmentResponse
+// @Failure     400
+// @Failure     403
+// @Failure     404
+// @Failure     413
+// @Failure     422
+// @Failure     500
+// @Router      /lots/{id}/attachments [post]
+func (c *AttachmentController) Create(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	// readJSON caps the bodies at 1MB, an upload gets its own limit.
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxUploadSize+multipartOverhead)
+
+	err = r.ParseMultipartForm(multipartOverhead)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesError):
+			payloadTooLargeResponse(w, r, c.maxUploadSize)
+		default:
+			badRequestResponse(w, r, err)
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		failedValidationResponse(w, r, map[string]string{"file": "must be provided"})
+		return
+	}
+	defer file.Close()
+
+	// The content type is taken from the content, not from what the client
+	// says it is.
+	sniff := make([]byte, 512)
+
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	attachment := &entity.Attachment{
+		LotID:       lotID,
+		Filename:    filepath.Base(header.Filename),
+		ContentType: http.DetectContentType(sniff[:n]),
+		Size:        header.Size,
+	}
+
+	v := validator.New()
+
+	if entity.ValidateAttachment(v, attachment, c.maxUploadSize); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = c.uc.Create(attachment, file, contextGetUser(r))
+	if err != nil {
+		attachmentErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusCreated, attachmentResponse{attachment}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Delete attachment
+// @Description detach the photo or document from the lot and remove its files
+// @ID          delete-attachment
+// @Tags        attachments
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       attachment_id path     int    true "Attachment ID"            Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200
+// @Failure     403
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/attachments/{attachment_id} [delete]
+func (c *AttachmentController) Delete(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	id, err := readIDParam("AttachmentID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	err = c.uc.Delete(lotID, id, contextGetUser(r))
+	if err != nil {
+		attachmentErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, envelope{"message": "attachment successfully deleted"}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// attachmentErrorResponse sends the response matching an error returned while
+// handling an attachment.
+func attachmentErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *entity.ValidationError
+	var policyErr *entity.PolicyError
+
+	switch {
+	case errors.Is(err, entity.ErrRecordNotFound):
+		notFoundResponse(w, r)
+	case errors.As(err, &policyErr):
+		forbiddenResponse(w, r, policyErr.Reason)
+	case errors.As(err, &validationErr):
+		failedValidationResponse(w, r, validationErr.Errors)
+	default:
+		serverErrorResponse(w, r, err)
+	}
+}
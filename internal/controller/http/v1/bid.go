@@ -10,8 +10,11 @@ import (
 )
 
 type BidUseCase interface {
-	List(lotID int64) ([]*entity.Bid, error)
-	Create(bid *entity.Bid) error
+	List(lot *entity.Lot, userID int64) ([]*entity.Bid, error)
+	Create(bid *entity.Bid) ([]*entity.Bid, error)
+	ShowProxy(lotID, bidderID int64) (*entity.ProxyBid, error)
+	SetProxy(proxy *entity.ProxyBid) ([]*entity.Bid, error)
+	DeleteProxy(lotID, bidderID int64) error
 }
 
 type BidController struct {
@@ -27,8 +30,28 @@ type listBidResponse struct {
 	Bid []*entity.Bid `json:"bids"`
 }
 
+type bidRequest struct {
+	Bid *entity.BaseBid `json:"bid"`
+}
+
+type bidResponse struct {
+	Bid         *entity.Bid   `json:"bid"`
+	CounterBids []*entity.Bid `json:"counter_bids,omitempty"`
+}
+
+type proxyBidRequest struct {
+	Proxy struct {
+		MaxPrice int64 `json:"max_price" example:"250000"`
+	} `json:"proxy"`
+}
+
+type proxyBidResponse struct {
+	Proxy       *entity.ProxyBid `json:"proxy"`
+	CounterBids []*entity.Bid    `json:"counter_bids,omitempty"`
+}
+
 // @Summary     Show bid list
-// @Description Show all bid list
+// @Description Show all bid list, the bids on an open sealed-bid lot are only shown to their bidders
 // @ID          bidList
 // @Tags        bids
 // @Accept      json
@@ -45,7 +68,7 @@ func (c *BidController) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = c.ucl.Show(lotID)
+	lot, err := c.ucl.Show(lotID)
 	if err != nil {
 		switch {
 		case errors.Is(err, entity.ErrRecordNotFound):
@@ -56,7 +79,9 @@ func (c *BidController) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bids, err := c.uc.List(lotID)
+	user := contextGetUser(r)
+
+	bids, err := c.uc.List(lot, user.ID)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 		return
@@ -75,10 +100,13 @@ func (c *BidController) List(w http.ResponseWriter, r *http.Request) {
 // @Tags        bids
 // @Accept      json
 // @Produce     json
-// @Param       id            path   int    true "Lot ID"                   Format(int64)
+// @Param       id            path   int        true  "Lot ID"                   Format(int64)
+// @Param       bid           body   bidRequest false "Create Bid"
 // @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
-// @Success     201
+// @Success     201           {object} bidResponse
 // @Failure     400
+// @Failure     404
+// @Failure     409
 // @Failure     422
 // @Failure     500
 // @Router      /lots/{id}/bids [post]
@@ -89,25 +117,35 @@ func (c *BidController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	lot, err := c.ucl.Show(lotID)
-	if err != nil {
-		switch {
-		case errors.Is(err, entity.ErrRecordNotFound):
-			notFoundResponse(w, r)
-		default:
-			serverErrorResponse(w, r, err)
+	user := contextGetUser(r)
+
+	// The request body is optional, a bid without one raises the price by one
+	// step whatever the current price is.
+	var input bidRequest
+
+	if r.ContentLength != 0 {
+		err = readJSON(w, r, &input)
+		if err != nil {
+			badRequestResponse(w, r, err)
+			return
 		}
-		return
 	}
 
-	user := contextGetUser(r)
-
 	bid := &entity.Bid{
-		Amount:   lot.StepPrice,
 		LotID:    lotID,
 		BidderID: &user.ID,
 	}
 
+	if fields := input.Bid; fields != nil {
+		if fields.Amount != nil {
+			bid.Amount = *fields.Amount
+		}
+		if fields.Price != nil {
+			bid.Price = *fields.Price
+		}
+		bid.LastPrice = fields.LastPrice
+	}
+
 	// Validate the record, sending the client a 422 Unprocessable Entity
 	// response if any checks fail.
 	v := validator.New()
@@ -119,9 +157,9 @@ func (c *BidController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.uc.Create(bid)
+	counters, err := c.uc.Create(bid)
 	if err != nil {
-		serverErrorResponse(w, r, err)
+		bidErrorResponse(w, r, err)
 		return
 	}
 
@@ -130,11 +168,173 @@ func (c *BidController) Create(w http.ResponseWriter, r *http.Request) {
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/lots/%d/bids/%d", lotID, bid.ID))
 
-	// Write a JSON response with a 201 Created status code, the lot data in the
-	// response body, and the Location header.
-	err = writeJSON(w, http.StatusCreated, envelope{"bid": bid}, headers)
+	// Write a JSON response with a 201 Created status code, the bid and the proxy
+	// counter-bids it triggered in the response body, and the Location header.
+	err = writeJSON(w, http.StatusCreated, bidResponse{bid, counters}, headers)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+
+}
+
+// Get          godoc
+// @Summary     Show proxy bid
+// @Description show the proxy bid of the current user
+// @ID          proxy-bid
+// @Tags        bids
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} proxyBidResponse
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/proxy [get]
+func (c *BidController) ShowProxy(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	proxy, err := c.uc.ShowProxy(lotID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, proxyBidResponse{Proxy: proxy}, nil)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 	}
+}
 
+// Get          godoc
+// @Summary     Set proxy bid
+// @Description set the maximum up to which the system bids for the current user
+// @ID          set-proxy-bid
+// @Tags        bids
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int             true "Lot ID" Format(int64)
+// @Param       proxy         body     proxyBidRequest true "Set Proxy Bid"
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} proxyBidResponse
+// @Failure     400
+// @Failure     404
+// @Failure     409
+// @Failure     422
+// @Failure     500
+// @Router      /lots/{id}/proxy [put]
+func (c *BidController) SetProxy(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	var input proxyBidRequest
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	proxy := &entity.ProxyBid{
+		MaxPrice: input.Proxy.MaxPrice,
+		LotID:    lotID,
+		BidderID: &user.ID,
+	}
+
+	v := validator.New()
+
+	if entity.ValidateProxyBid(v, proxy); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	counters, err := c.uc.SetProxy(proxy)
+	if err != nil {
+		bidErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, proxyBidResponse{proxy, counters}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Delete proxy bid
+// @Description stop bidding automatically for the current user
+// @ID          delete-proxy-bid
+// @Tags        bids
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/proxy [delete]
+func (c *BidController) DeleteProxy(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	err = c.uc.DeleteProxy(lotID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, envelope{"message": "proxy bid successfully deleted"}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// bidErrorResponse sends the response matching an error returned while placing a
+// bid on a lot.
+func bidErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *entity.ValidationError
+
+	switch {
+	case errors.Is(err, entity.ErrRecordNotFound):
+		notFoundResponse(w, r)
+	case errors.Is(err, entity.ErrAuctionNotStarted):
+		auctionNotStartedResponse(w, r)
+	case errors.Is(err, entity.ErrAuctionClosed):
+		auctionClosedResponse(w, r)
+	case errors.Is(err, entity.ErrStalePrice):
+		stalePriceResponse(w, r)
+	case errors.Is(err, entity.ErrWrongAuctionType):
+		wrongAuctionTypeResponse(w, r)
+	case errors.Is(err, entity.ErrInsufficientFunds):
+		insufficientFundsResponse(w, r)
+	case errors.As(err, &validationErr):
+		failedValidationResponse(w, r, validationErr.Errors)
+	default:
+		serverErrorResponse(w, r, err)
+	}
 }
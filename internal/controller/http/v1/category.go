@@ -0,0 +1,289 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+type CategoryUseCase interface {
+	List() ([]*entity.Category, error)
+	Show(id int64) (*entity.Category, error)
+	Create(category *entity.Category) error
+	Update(category *entity.Category) error
+	Delete(id int64) error
+}
+
+type CategoryController struct {
+	uc CategoryUseCase
+}
+
+func NewCategoryController(uc CategoryUseCase) *CategoryController {
+	return &CategoryController{uc: uc}
+}
+
+type listCategoryResponse struct {
+	Category []*entity.Category `json:"categories"`
+}
+
+type categoryResponse struct {
+	Category *entity.Category `json:"category"`
+}
+
+type categoryRequest struct {
+	Category *entity.BaseCategory `json:"category"`
+}
+
+// @Summary     Show category list
+// @Description Show all categories with the number of active lots in each of them
+// @ID          categoryList
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} listCategoryResponse
+// @Failure     500
+// @Router      /categories [get]
+func (c *CategoryController) List(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.uc.List()
+	if err != nil {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, listCategoryResponse{categories}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Show category
+// @Description show category
+// @ID          category
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Category ID"              Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} categoryResponse
+// @Failure     404
+// @Failure     500
+// @Router      /categories/{id} [get]
+func (c *CategoryController) Show(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	category, err := c.uc.Show(id)
+	if err != nil {
+		categoryErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, categoryResponse{category}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Create category
+// @Description create category, admins only
+// @ID          create-category
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       category      body     categoryRequest true "Create Category"
+// @Param       Authorization header   string          true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     201           {object} categoryResponse
+// @Failure     400
+// @Failure     403
+// @Failure     422
+// @Failure     500
+// @Router      /categories [post]
+func (c *CategoryController) Create(w http.ResponseWriter, r *http.Request) {
+	var input categoryRequest
+
+	err := readJSON(w, r, &input)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Category == nil {
+		badRequestResponse(w, r, errors.New("body must contain a category"))
+		return
+	}
+
+	fields := input.Category
+	category := &entity.Category{
+		ParentID: fields.ParentID,
+		Name:     fields.Name,
+		Slug:     fields.Slug,
+	}
+
+	if category.ParentID != nil && *category.ParentID == 0 {
+		category.ParentID = nil
+	}
+
+	v := validator.New()
+
+	if entity.ValidateCategory(v, category); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = c.uc.Create(category)
+	if err != nil {
+		categoryErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/categories/%d", category.ID))
+
+	err = writeJSON(w, http.StatusCreated, categoryResponse{category}, headers)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Update category
+// @Description update category, admins only; a zero parent_id moves it to the top level
+// @ID          update-category
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int             true "Category ID" Format(int64)
+// @Param       category      body     categoryRequest true "Update Category"
+// @Param       Authorization header   string          true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} categoryResponse
+// @Failure     400
+// @Failure     403
+// @Failure     404
+// @Failure     422
+// @Failure     500
+// @Router      /categories/{id} [patch]
+func (c *CategoryController) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	category, err := c.uc.Show(id)
+	if err != nil {
+		categoryErrorResponse(w, r, err)
+		return
+	}
+
+	var input categoryRequest
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Category == nil {
+		badRequestResponse(w, r, errors.New("body must contain a category"))
+		return
+	}
+
+	fields := input.Category
+
+	if fields.ParentID != nil {
+		category.ParentID = fields.ParentID
+		if *fields.ParentID == 0 {
+			category.ParentID = nil
+		}
+	}
+
+	if fields.Name != "" {
+		category.Name = fields.Name
+	}
+
+	if fields.Slug != "" {
+		category.Slug = fields.Slug
+	}
+
+	v := validator.New()
+
+	if entity.ValidateCategory(v, category); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = c.uc.Update(category)
+	if err != nil {
+		categoryErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, categoryResponse{category}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Delete category
+// @Description delete category, admins only; its lots are left without a category
+// @ID          delete-category
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Category ID"              Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200
+// @Failure     403
+// @Failure     404
+// @Failure     409
+// @Failure     500
+// @Router      /categories/{id} [delete]
+func (c *CategoryController) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	err = c.uc.Delete(id)
+	if err != nil {
+		categoryErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, envelope{"message": "category successfully deleted"}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// categoryErrorResponse sends the response matching an error returned while
+// handling a category.
+func categoryErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *entity.ValidationError
+
+	switch {
+	case errors.Is(err, entity.ErrRecordNotFound):
+		notFoundResponse(w, r)
+	case errors.Is(err, entity.ErrDuplicateSlug):
+		failedValidationResponse(w, r, map[string]string{"slug": "a category with this slug already exists"})
+	case errors.Is(err, entity.ErrUnknownCategory):
+		failedValidationResponse(w, r, map[string]string{"parent_id": "must be an existing category"})
+	case errors.Is(err, entity.ErrCategoryNotEmpty):
+		categoryNotEmptyResponse(w, r)
+	case errors.As(err, &validationErr):
+		failedValidationResponse(w, r, validationErr.Errors)
+	default:
+		serverErrorResponse(w, r, err)
+	}
+}
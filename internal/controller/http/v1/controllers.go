@@ -1,21 +1,35 @@
 package v1
 
-import "github.com/ElOtro/auction-go/internal/usecase"
+import (
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/usecase"
+)
 
 // Create a Controllers struct which wraps all controllers.
 type Controllers struct {
-	Lot     LotController
-	Bid     BidController
-	User    UserController
-	Session SessionController
+	Lot        LotController
+	Bid        BidController
+	Event      EventController
+	WS         WSController
+	Account    AccountController
+	Category   CategoryController
+	Attachment AttachmentController
+	User       UserController
+	Session    SessionController
 }
 
 // For ease of use, we also add a NewControllers() method which returns a Controllers struct
-func NewControllers(usecases *usecase.UseCases, jwtSecret string) Controllers {
+func NewControllers(usecases *usecase.UseCases, jwtSecret string, heartbeat time.Duration, maxUploadSize int64, allowedOrigins []string) Controllers {
 	return Controllers{
-		Lot:     *NewLotController(&usecases.Lot),
-		Bid:     *NewBidController(&usecases.Bid, &usecases.Lot),
-		User:    *NewUserController(&usecases.User),
-		Session: *NewSessionController(&usecases.User, jwtSecret),
+		Lot:        *NewLotController(&usecases.Lot),
+		Bid:        *NewBidController(&usecases.Bid, &usecases.Lot),
+		Event:      *NewEventController(&usecases.Event, heartbeat),
+		WS:         *NewWSController(&usecases.Bid, &usecases.Event, allowedOrigins),
+		Account:    *NewAccountController(&usecases.Account),
+		Category:   *NewCategoryController(&usecases.Category),
+		Attachment: *NewAttachmentController(&usecases.Attachment, maxUploadSize),
+		User:       *NewUserController(&usecases.User),
+		Session:    *NewSessionController(&usecases.User, jwtSecret),
 	}
 }
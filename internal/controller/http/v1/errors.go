@@ -59,3 +59,66 @@ func invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request)
 	message := "invalid or missing authentication token"
 	errorResponse(w, r, http.StatusUnauthorized, message)
 }
+
+func auctionNotStartedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the auction for this lot has not started yet"
+	errorResponse(w, r, http.StatusUnprocessableEntity, message)
+}
+
+func auctionClosedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the auction for this lot is closed"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func stalePriceResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the lot price has changed since you last saw it, please reload it and try again"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func buyNowUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the lot can no longer be bought at the buy-now price"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func wrongAuctionTypeResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this action is not supported by the auction type of the lot"
+	errorResponse(w, r, http.StatusUnprocessableEntity, message)
+}
+
+func notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account doesn't have the necessary permissions to access this resource"
+	errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func insufficientFundsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "there are not enough available funds in the account"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func offerClosedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the offer is no longer open"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func categoryNotEmptyResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the category has subcategories, move or delete them first"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func unknownCategoryResponse(w http.ResponseWriter, r *http.Request) {
+	failedValidationResponse(w, r, map[string]string{"category_id": "must be an existing category"})
+}
+
+func payloadTooLargeResponse(w http.ResponseWriter, r *http.Request, maxSize int64) {
+	message := fmt.Sprintf("the file must not be larger than %d bytes", maxSize)
+	errorResponse(w, r, http.StatusRequestEntityTooLarge, message)
+}
+
+func editConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please reload it and try again"
+	errorResponse(w, r, http.StatusConflict, message)
+}
+
+func forbiddenResponse(w http.ResponseWriter, r *http.Request, reason string) {
+	errorResponse(w, r, http.StatusForbidden, reason)
+}
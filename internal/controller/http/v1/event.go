@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+const _defaultHeartbeat = 15 * time.Second
+
+type EventUseCase interface {
+	Subscribe(lotID, lastID int64) ([]*entity.LotEvent, <-chan *entity.LotEvent, func(), error)
+}
+
+type EventController struct {
+	uc        EventUseCase
+	heartbeat time.Duration
+}
+
+func NewEventController(uc EventUseCase, heartbeat time.Duration) *EventController {
+	if heartbeat <= 0 {
+		heartbeat = _defaultHeartbeat
+	}
+
+	return &EventController{uc: uc, heartbeat: heartbeat}
+}
+
+// Get          godoc
+// @Summary     Stream lot events
+// @Description stream new bids, price changes, end time extensions and status changes of the lot as server-sent events
+// @ID          lot-events
+// @Tags        lots
+// @Produce     text/event-stream
+// @Param       id            path   int    true  "Lot ID"                   Format(int64)
+// @Param       Last-Event-ID header int    false "Resume after the event"
+// @Param       Authorization header string true  "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} entity.LotEvent
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/events [get]
+func (c *EventController) Stream(w http.ResponseWriter, r *http.Request) {
+	lotID, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	// A reconnecting browser sends the id of the last event it has seen.
+	var lastID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		lastID, err = strconv.ParseInt(header, 10, 64)
+		if err != nil || lastID < 0 {
+			badRequestResponse(w, r, errors.New("invalid Last-Event-ID header"))
+			return
+		}
+	}
+
+	backlog, events, cancel, err := c.uc.Subscribe(lotID, lastID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	defer cancel()
+
+	// The write timeout of the server would cut the stream off after a few
+	// seconds, so it is lifted for this response. The heartbeats let the client
+	// and any proxy in between know the connection is still alive.
+	rc := http.NewResponseController(w)
+
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range backlog {
+		err = writeEvent(w, event)
+		if err != nil {
+			return
+		}
+	}
+
+	err = rc.Flush()
+	if err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			// The subscription has been dropped, the client reconnects and
+			// resumes with Last-Event-ID.
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+
+		err = rc.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes the event in the server-sent events format.
+func writeEvent(w http.ResponseWriter, event *entity.LotEvent) error {
+	js, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, js)
+
+	return err
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ElOtro/auction-go/internal/validator"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -152,3 +155,115 @@ func readJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
 
 	return nil
 }
+
+// The readString() helper returns a string value from the query string, or the
+// provided default value if no matching key could be found.
+func readString(qs url.Values, key string, defaultValue string) string {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	return s
+}
+
+// The readInt() helper reads a string value from the query string and converts it
+// to an integer before returning. If no matching key could be found it returns
+// the provided default value. If the value couldn't be converted to an integer,
+// then we record an error message in the provided Validator instance.
+func readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
+
+// The readBool() helper reads a boolean value ("true", "false", "1", "0") from
+// the query string, or returns the provided default value if there is none.
+func readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+// The readOptionalInt() helper works like readInt() for a filter which may be
+// left out, it returns nil then.
+func readOptionalInt(qs url.Values, key string, v *validator.Validator) *int64 {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return nil
+	}
+
+	return &i
+}
+
+// The readOptionalTime() helper reads an RFC 3339 time from the query string, it
+// returns nil if there is none.
+func readOptionalTime(qs url.Values, key string, v *validator.Validator) *time.Time {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		v.AddError(key, "must be an RFC 3339 time")
+		return nil
+	}
+
+	return &t
+}
+
+// etag returns the entity tag of a record at the given version, sent in the
+// ETag header and expected back in If-Match.
+func etag(version int32) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatch reports whether the If-Match header of the request lets a record with
+// the given entity tag be changed. A request without the header or with "*"
+// always does, otherwise one of the listed tags has to be the same. Weak tags
+// never match.
+func ifMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+
+	return false
+}
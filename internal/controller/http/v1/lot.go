@@ -4,17 +4,28 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/ElOtro/auction-go/internal/entity"
 	"github.com/ElOtro/auction-go/internal/validator"
 )
 
 type LotUseCase interface {
-	List() ([]*entity.Lot, error)
+	List(filters entity.LotFilters) ([]*entity.Lot, entity.Metadata, error)
 	Show(id int64) (*entity.Lot, error)
+	Edit(id int64, user *entity.User) (*entity.Lot, error)
 	Create(lot *entity.Lot) error
-	Update(lot *entity.Lot) error
-	Delete(id int64) error
+	Update(lot *entity.Lot, user *entity.User) error
+	Delete(id int64, user *entity.User) error
+	Buy(id, buyerID int64) (*entity.Lot, *entity.Bid, error)
+	Accept(id, bidderID int64) (*entity.Lot, *entity.Bid, error)
+	ShowOffer(id, bidderID int64) (*entity.Offer, error)
+	AcceptOffer(id, bidderID int64) (*entity.Lot, *entity.Offer, error)
+	DeclineOffer(id, bidderID int64) (*entity.Offer, error)
+	History(id int64, user *entity.User) ([]*entity.LotHistory, error)
+	Search(query entity.LotSearchQuery) ([]*entity.LotSearch, entity.Metadata, error)
+	Trash(filters entity.Filters) ([]*entity.Lot, entity.Metadata, error)
+	Restore(id int64) (*entity.Lot, error)
 }
 
 type LotController struct {
@@ -26,7 +37,13 @@ func NewLotController(uc LotUseCase) *LotController {
 }
 
 type listLotResponse struct {
-	Lot []*entity.Lot `json:"lots"`
+	Lot      []*entity.Lot   `json:"lots"`
+	Metadata entity.Metadata `json:"metadata"`
+}
+
+type searchLotResponse struct {
+	Lot      []*entity.LotSearch `json:"lots"`
+	Metadata entity.Metadata     `json:"metadata"`
 }
 
 type lotResponse struct {
@@ -37,33 +54,149 @@ type lotRequest struct {
 	Lot *entity.BaseLot `json:"lot"`
 }
 
-type lotUpdate struct {
-	Status *entity.LotStatus `json:"status" example:"1"`
-	*entity.BaseLot
+type buyLotResponse struct {
+	Lot *entity.Lot `json:"lot"`
+	Bid *entity.Bid `json:"bid"`
+}
+
+type offerResponse struct {
+	Offer *entity.Offer `json:"offer"`
+}
+
+type acceptOfferResponse struct {
+	Lot   *entity.Lot   `json:"lot"`
+	Offer *entity.Offer `json:"offer"`
+}
+
+type listLotHistoryResponse struct {
+	History []*entity.LotHistory `json:"history"`
 }
 
 type lotUpdateRequest struct {
-	Lot lotUpdate `json:"lot"`
+	Lot entity.BaseLot `json:"lot"`
 }
 
 // @Summary     Show lot list
-// @Description Show all lot list
+// @Description Show a page of the lots matching the filters
 // @ID          lotList
 // @Tags        lots
 // @Accept      json
 // @Produce     json
-// @Param       Authorization header string     true "Insert your access token" default(Bearer <Add access token here>)
+// @Param       title         query    string false "Part of the title"
+// @Param       status        query    int    false "Status (1, 2, 4 or 8)"
+// @Param       creator_id    query    int    false "Creator ID"
+// @Param       category_id   query    int    false "Category ID, with its subcategories"
+// @Param       tag           query    string false "Tag"
+// @Param       min_price     query    int    false "Lowest start price"
+// @Param       max_price     query    int    false "Highest start price"
+// @Param       start_from    query    string false "Starting at or after (RFC 3339)"
+// @Param       start_to      query    string false "Starting at or before (RFC 3339)"
+// @Param       end_from      query    string false "Ending at or after (RFC 3339)"
+// @Param       end_to        query    string false "Ending at or before (RFC 3339)"
+// @Param       page          query    int    false "Page"      default(1)
+// @Param       page_size     query    int    false "Page size" default(20)
+// @Param       sort          query    string false "Sort column, descending with a - prefix" Enums(id, title, start_price, start_at, end_at, created_at, -id, -title, -start_price, -start_at, -end_at, -created_at)
+// @Param       Authorization header   string true  "Insert your access token" default(Bearer <Add access token here>)
 // @Success     200           {object} listLotResponse
+// @Failure     422
 // @Failure     500
 // @Router      /lots [get]
 func (c *LotController) List(w http.ResponseWriter, r *http.Request) {
-	lots, err := c.uc.List()
+	user := contextGetUser(r)
+
+	var filters entity.LotFilters
+
+	v := validator.New()
+
+	// Read the filters from the query string, any of them may be left out.
+	qs := r.URL.Query()
+
+	filters.Title = readString(qs, "title", "")
+	if status := readOptionalInt(qs, "status", v); status != nil {
+		s := entity.LotStatus(*status)
+		filters.Status = &s
+	}
+	filters.CreatorID = readOptionalInt(qs, "creator_id", v)
+	filters.CategoryID = readOptionalInt(qs, "category_id", v)
+	filters.Tag = strings.ToLower(strings.TrimSpace(readString(qs, "tag", "")))
+	filters.MinPrice = readOptionalInt(qs, "min_price", v)
+	filters.MaxPrice = readOptionalInt(qs, "max_price", v)
+	filters.StartFrom = readOptionalTime(qs, "start_from", v)
+	filters.StartTo = readOptionalTime(qs, "start_to", v)
+	filters.EndFrom = readOptionalTime(qs, "end_from", v)
+	filters.EndTo = readOptionalTime(qs, "end_to", v)
+
+	filters.Page = readInt(qs, "page", 1, v)
+	filters.PageSize = readInt(qs, "page_size", 20, v)
+	filters.Sort = readString(qs, "sort", "id")
+	filters.SortSafelist = []string{"id", "title", "start_price", "start_at", "end_at", "created_at",
+		"-id", "-title", "-start_price", "-start_at", "-end_at", "-created_at"}
+
+	if entity.ValidateLotFilters(v, filters); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	lots, metadata, err := c.uc.List(filters)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	for _, lot := range lots {
+		lot.HideReserve(user.ID)
+	}
+
+	err = writeJSON(w, http.StatusOK, listLotResponse{lots, metadata}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Search lots
+// @Description Find the lots whose title or description match the query, the best match first, with the matching words highlighted
+// @ID          lotSearch
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       q             query    string true  "Search query (quoted phrases, or, -word)"
+// @Param       prefix        query    bool   false "Match the last word as a prefix, for type-ahead"
+// @Param       page          query    int    false "Page"      default(1)
+// @Param       page_size     query    int    false "Page size" default(20)
+// @Param       Authorization header   string true  "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} searchLotResponse
+// @Failure     422
+// @Failure     500
+// @Router      /lots/search [get]
+func (c *LotController) Search(w http.ResponseWriter, r *http.Request) {
+	var query entity.LotSearchQuery
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	query.Query = readString(qs, "q", "")
+	query.Prefix = readBool(qs, "prefix", false, v)
+
+	// The results are always ranked by how well they match.
+	query.Page = readInt(qs, "page", 1, v)
+	query.PageSize = readInt(qs, "page_size", 20, v)
+	query.Sort = "rank"
+	query.SortSafelist = []string{"rank"}
+
+	if entity.ValidateLotSearchQuery(v, query); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	lots, metadata, err := c.uc.Search(query)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = writeJSON(w, http.StatusOK, listLotResponse{lots}, nil)
+	err = writeJSON(w, http.StatusOK, searchLotResponse{lots, metadata}, nil)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 	}
@@ -99,7 +232,12 @@ func (c *LotController) Show(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = writeJSON(w, http.StatusOK, lotResponse{lot}, nil)
+	lot.HideReserve(contextGetUser(r).ID)
+
+	headers := make(http.Header)
+	headers.Set("ETag", etag(lot.Version))
+
+	err = writeJSON(w, http.StatusOK, lotResponse{lot}, headers)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 	}
@@ -131,15 +269,30 @@ func (c *LotController) Create(w http.ResponseWriter, r *http.Request) {
 
 	var fields = input.Lot
 	lot := &entity.Lot{
-		Status:      entity.LotPending,
-		Title:       fields.Title,
-		Description: fields.Description,
-		StartPrice:  *fields.StartPrice,
-		StepPrice:   *fields.StepPrice,
-		StartAt:     *fields.StartAt,
-		EndAt:       *fields.EndAt,
-		Notify:      fields.Notify,
-		CreatorID:   &user.ID,
+		Status:       entity.LotPending,
+		Type:         entity.LotEnglish,
+		Title:        fields.Title,
+		Description:  fields.Description,
+		StartPrice:   *fields.StartPrice,
+		StepPrice:    *fields.StepPrice,
+		ReservePrice: fields.ReservePrice,
+		BuyNowPrice:  fields.BuyNowPrice,
+		StartAt:      *fields.StartAt,
+		EndAt:        *fields.EndAt,
+		SoftClose:    fields.SoftClose,
+		Dutch:        fields.Dutch,
+		Notify:       fields.Notify,
+		CategoryID:   fields.CategoryID,
+		Tags:         entity.NormalizeTags(fields.Tags),
+		CreatorID:    &user.ID,
+	}
+
+	if fields.Type != nil {
+		lot.Type = *fields.Type
+	}
+
+	if lot.CategoryID != nil && *lot.CategoryID == 0 {
+		lot.CategoryID = nil
 	}
 
 	// Initialize a new Validator instance.
@@ -154,7 +307,12 @@ func (c *LotController) Create(w http.ResponseWriter, r *http.Request) {
 
 	err = c.uc.Create(lot)
 	if err != nil {
-		serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, entity.ErrUnknownCategory):
+			unknownCategoryResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -162,6 +320,7 @@ func (c *LotController) Create(w http.ResponseWriter, r *http.Request) {
 	// client know which URL they can find the newly-created resource at.
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/lots/%d", lot.ID))
+	headers.Set("ETag", etag(lot.Version))
 
 	// Write a JSON response with a 201 Created status code, the lot data in the
 	// response body, and the Location header.
@@ -174,16 +333,20 @@ func (c *LotController) Create(w http.ResponseWriter, r *http.Request) {
 
 // Get          godoc
 // @Summary     Update lot
-// @Description update lot
+// @Description update lot, with If-Match set only if it hasn't changed since its ETag was read. The status of the lot can't be set
 // @ID          update-lot
 // @Tags        lots
 // @Accept      json
 // @Produce     json
-// @Param       id            path     int              true "Lot ID" Format(int64)
-// @Param       lot           body     lotUpdateRequest true "Update Lot"
+// @Param       id            path     int              true  "Lot ID" Format(int64)
+// @Param       lot           body     lotUpdateRequest true  "Update Lot"
+// @Param       If-Match      header   string           false "ETag of the lot"
 // @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
 // @Success     200           {object} lotResponse
 // @Failure     400
+// @Failure     403
+// @Failure     404
+// @Failure     409
 // @Failure     500
 // @Router      /lots/{id} [patch]
 func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
@@ -195,18 +358,31 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch the existing record from the database, sending a 404 Not Found
-	// response to the client if we couldn't find a matching record.
-	lot, err := c.uc.Show(id)
+	// response to the client if we couldn't find a matching record. A user who
+	// may not change the lot gets a 403 Forbidden before anything else is
+	// checked.
+	lot, err := c.uc.Edit(id, contextGetUser(r))
 	if err != nil {
+		var policyErr *entity.PolicyError
+
 		switch {
 		case errors.Is(err, entity.ErrRecordNotFound):
 			notFoundResponse(w, r)
+		case errors.As(err, &policyErr):
+			forbiddenResponse(w, r, policyErr.Reason)
 		default:
 			serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
+	// A client sending If-Match only updates the lot it has seen, any change
+	// since then is a conflict.
+	if !ifMatch(r, etag(lot.Version)) {
+		editConflictResponse(w, r)
+		return
+	}
+
 	// Declare an input struct to hold the expected data from the client.
 	var input lotUpdateRequest
 
@@ -216,10 +392,11 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The status of the lot is left to the auction itself, it can't be set here.
 	var fields = input.Lot
 
-	if fields.Status != nil {
-		lot.Status = *fields.Status
+	if fields.Type != nil {
+		lot.Type = *fields.Type
 	}
 
 	if fields.Title != "" {
@@ -238,6 +415,14 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 		lot.StepPrice = *fields.StepPrice
 	}
 
+	if fields.ReservePrice != nil {
+		lot.ReservePrice = fields.ReservePrice
+	}
+
+	if fields.BuyNowPrice != nil {
+		lot.BuyNowPrice = fields.BuyNowPrice
+	}
+
 	if fields.StartAt != nil {
 		lot.StartAt = *fields.StartAt
 	}
@@ -246,6 +431,36 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 		lot.EndAt = *fields.EndAt
 	}
 
+	// A soft close with a zero window switches it off.
+	if fields.SoftClose != nil {
+		lot.SoftClose = fields.SoftClose
+		if fields.SoftClose.Window == 0 && fields.SoftClose.Extension == 0 {
+			lot.SoftClose = nil
+		}
+	}
+
+	if fields.Dutch != nil {
+		lot.Dutch = fields.Dutch
+	}
+
+	// A lot changed to another type of auction drops its descending price,
+	// unless the client sent one along (which fails the validation).
+	if lot.Type != entity.LotDutch && fields.Dutch == nil {
+		lot.Dutch = nil
+	}
+
+	// A zero category takes the lot out of its category.
+	if fields.CategoryID != nil {
+		lot.CategoryID = fields.CategoryID
+		if *fields.CategoryID == 0 {
+			lot.CategoryID = nil
+		}
+	}
+
+	if fields.Tags != nil {
+		lot.Tags = entity.NormalizeTags(fields.Tags)
+	}
+
 	lot.Notify = fields.Notify
 
 	// Validate the updated lot record, sending the client a 422 Unprocessable Entity
@@ -257,31 +472,45 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.uc.Update(lot)
+	err = c.uc.Update(lot, contextGetUser(r))
 	if err != nil {
-		serverErrorResponse(w, r, err)
+		var policyErr *entity.PolicyError
+
+		switch {
+		case errors.As(err, &policyErr):
+			forbiddenResponse(w, r, policyErr.Reason)
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		case errors.Is(err, entity.ErrEditConflict):
+			editConflictResponse(w, r)
+		case errors.Is(err, entity.ErrUnknownCategory):
+			unknownCategoryResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	responseLot := entity.Lot{
-		ID:          lot.ID,
-		Status:      lot.Status,
-		Title:       lot.Title,
-		Description: lot.Description,
-		StartPrice:  lot.StartPrice,
-		EndPrice:    lot.EndPrice,
-		StepPrice:   lot.StepPrice,
-		CreatorID:   lot.CreatorID,
-		WinnerID:    lot.WinnerID,
-		StartAt:     lot.StartAt,
-		EndAt:       lot.EndAt,
-		Notify:      lot.Notify,
-		CreatedAt:   lot.CreatedAt,
-		UpdatedAt:   lot.UpdatedAt,
+	// Read the lot back with everything Show returns, its attachments and the
+	// prices and times worked out from the stored record.
+	lot, err = c.uc.Show(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
 	}
 
+	lot.HideReserve(contextGetUser(r).ID)
+
+	headers := make(http.Header)
+	headers.Set("ETag", etag(lot.Version))
+
 	// Write the updated lot record in a JSON response.
-	err = writeJSON(w, http.StatusOK, envelope{"lot": responseLot}, nil)
+	err = writeJSON(w, http.StatusOK, lotResponse{lot}, headers)
 	if err != nil {
 		serverErrorResponse(w, r, err)
 	}
@@ -290,13 +519,14 @@ func (c *LotController) Update(w http.ResponseWriter, r *http.Request) {
 
 // Get          godoc
 // @Summary     Delete lot
-// @Description delete lot
+// @Description move the lot to the trash, it is purged after the retention period. A lot can't be deleted while its auction is running or it is waiting for the payment
 // @ID          delete-lot
 // @Tags        lots
 // @Accept      json
 // @Produce     json
 // @Param       id path int true "Lot ID" Format(int64)
 // @Success     200
+// @Failure     403
 // @Failure     404
 // @Failure     500
 // @Router      /lots/{id} [delete]
@@ -310,9 +540,13 @@ func (c *LotController) Delete(w http.ResponseWriter, r *http.Request) {
 
 	// Delete the record from the database, sending a 404 Not Found response to the
 	// client if there isn't a matching record.
-	err = c.uc.Delete(id)
+	err = c.uc.Delete(id, contextGetUser(r))
 	if err != nil {
+		var policyErr *entity.PolicyError
+
 		switch {
+		case errors.As(err, &policyErr):
+			forbiddenResponse(w, r, policyErr.Reason)
 		case errors.Is(err, entity.ErrRecordNotFound):
 			notFoundResponse(w, r)
 		default:
@@ -327,3 +561,335 @@ func (c *LotController) Delete(w http.ResponseWriter, r *http.Request) {
 		serverErrorResponse(w, r, err)
 	}
 }
+
+// Get          godoc
+// @Summary     Show trash
+// @Description show a page of the deleted lots which haven't been purged yet, admin only
+// @ID          lotTrash
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       page          query    int    false "Page"      default(1)
+// @Param       page_size     query    int    false "Page size" default(20)
+// @Param       sort          query    string false "Sort column, descending with a - prefix" Enums(id, title, destroyed_at, -id, -title, -destroyed_at)
+// @Param       Authorization header   string true  "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} listLotResponse
+// @Failure     403
+// @Failure     422
+// @Failure     500
+// @Router      /lots/trash [get]
+func (c *LotController) Trash(w http.ResponseWriter, r *http.Request) {
+	var filters entity.Filters
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	// The latest deleted lots come first unless asked otherwise.
+	filters.Page = readInt(qs, "page", 1, v)
+	filters.PageSize = readInt(qs, "page_size", 20, v)
+	filters.Sort = readString(qs, "sort", "-destroyed_at")
+	filters.SortSafelist = []string{"id", "title", "destroyed_at", "-id", "-title", "-destroyed_at"}
+
+	if entity.ValidateFilters(v, filters); !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	lots, metadata, err := c.uc.Trash(filters)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+		return
+	}
+
+	user := contextGetUser(r)
+	for _, lot := range lots {
+		lot.HideReserve(user.ID)
+	}
+
+	err = writeJSON(w, http.StatusOK, listLotResponse{lots, metadata}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Restore lot
+// @Description take the lot out of the trash, admin only
+// @ID          restore-lot
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} lotResponse
+// @Failure     403
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/restore [post]
+func (c *LotController) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	lot, err := c.uc.Restore(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	lot.HideReserve(contextGetUser(r).ID)
+
+	err = writeJSON(w, http.StatusOK, lotResponse{lot}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Buy lot
+// @Description buy lot at its buy-now price, the auction ends at once
+// @ID          buy-lot
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} buyLotResponse
+// @Failure     404
+// @Failure     409
+// @Failure     422
+// @Failure     500
+// @Router      /lots/{id}/buy [post]
+func (c *LotController) Buy(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	lot, bid, err := c.uc.Buy(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrBuyNowUnavailable):
+			buyNowUnavailableResponse(w, r)
+		default:
+			bidErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	lot.HideReserve(user.ID)
+
+	err = writeJSON(w, http.StatusOK, buyLotResponse{lot, bid}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Accept lot price
+// @Description accept the current asking price of a dutch lot, the first bidder to accept wins
+// @ID          accept-lot
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} buyLotResponse
+// @Failure     404
+// @Failure     409
+// @Failure     422
+// @Failure     500
+// @Router      /lots/{id}/accept [post]
+func (c *LotController) Accept(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	lot, bid, err := c.uc.Accept(id, user.ID)
+	if err != nil {
+		bidErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, buyLotResponse{lot, bid}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Show second-chance offer
+// @Description show the second-chance offer of the lot made to the current user
+// @ID          lot-offer
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} offerResponse
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/offer [get]
+func (c *LotController) ShowOffer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	offer, err := c.uc.ShowOffer(id, user.ID)
+	if err != nil {
+		offerErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, offerResponse{offer}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Accept second-chance offer
+// @Description buy the lot at the price of the second-chance offer made to the current user, the price is paid at once
+// @ID          accept-lot-offer
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} acceptOfferResponse
+// @Failure     404
+// @Failure     409
+// @Failure     500
+// @Router      /lots/{id}/offer/accept [post]
+func (c *LotController) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	lot, offer, err := c.uc.AcceptOffer(id, user.ID)
+	if err != nil {
+		offerErrorResponse(w, r, err)
+		return
+	}
+
+	lot.HideReserve(user.ID)
+
+	err = writeJSON(w, http.StatusOK, acceptOfferResponse{lot, offer}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// Get          godoc
+// @Summary     Decline second-chance offer
+// @Description turn down the second-chance offer made to the current user, the lot is offered to the next bidder
+// @ID          decline-lot-offer
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} offerResponse
+// @Failure     404
+// @Failure     409
+// @Failure     500
+// @Router      /lots/{id}/offer/decline [post]
+func (c *LotController) DeclineOffer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	offer, err := c.uc.DeclineOffer(id, user.ID)
+	if err != nil {
+		offerErrorResponse(w, r, err)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, offerResponse{offer}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// @Summary     Show lot history
+// @Description Show what has happened to the lot after the end of the auction: the sale, the payment and the second-chance offers. Only the creator, the winner, the bidders the lot has been offered to and admins can see it
+// @ID          lotHistory
+// @Tags        lots
+// @Accept      json
+// @Produce     json
+// @Param       id            path     int    true "Lot ID"                   Format(int64)
+// @Param       Authorization header   string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     200           {object} listLotHistoryResponse
+// @Failure     403
+// @Failure     404
+// @Failure     500
+// @Router      /lots/{id}/history [get]
+func (c *LotController) History(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam("ID", r)
+	if err != nil {
+		notFoundResponse(w, r)
+		return
+	}
+
+	history, err := c.uc.History(id, contextGetUser(r))
+	if err != nil {
+		var policyErr *entity.PolicyError
+
+		switch {
+		case errors.Is(err, entity.ErrRecordNotFound):
+			notFoundResponse(w, r)
+		case errors.As(err, &policyErr):
+			forbiddenResponse(w, r, policyErr.Reason)
+		default:
+			serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, listLotHistoryResponse{history}, nil)
+	if err != nil {
+		serverErrorResponse(w, r, err)
+	}
+}
+
+// offerErrorResponse sends the response matching an error returned while
+// handling a second-chance offer.
+func offerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, entity.ErrRecordNotFound):
+		notFoundResponse(w, r)
+	case errors.Is(err, entity.ErrOfferClosed):
+		offerClosedResponse(w, r)
+	case errors.Is(err, entity.ErrInsufficientFunds):
+		insufficientFundsResponse(w, r)
+	default:
+		serverErrorResponse(w, r, err)
+	}
+}
@@ -2,6 +2,8 @@
 package v1
 
 import (
+	"net/http"
+
 	_ "github.com/ElOtro/auction-go/docs" // docs is generated by Swag CLI, you have to import it.
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -12,12 +14,15 @@ import (
 // Create a Handlers struct which wraps all models.
 type Handlers struct {
 	controllers Controllers
+	files       http.Handler
+	filesURL    string
 }
 
 // For ease of use, we also add a NewHandlers() method which
-// returns a Handlers struct
-func NewHandlers(controllers Controllers) *Handlers {
-	return &Handlers{controllers: controllers}
+// returns a Handlers struct. The uploaded files are served by files under
+// filesURL.
+func NewHandlers(controllers Controllers, files http.Handler, filesURL string) *Handlers {
+	return &Handlers{controllers: controllers, files: files, filesURL: filesURL}
 }
 
 // NewHandlers -.
@@ -40,6 +45,9 @@ func (h *Handlers) Routes() *chi.Mux {
 		httpSwagger.URL("/swagger/doc.json"), // The url pointing to API definition
 	))
 
+	// Uploaded files
+	mux.Get(h.filesURL+"/*", h.files.ServeHTTP)
+
 	// Routers
 	mux.Route("/v1", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
@@ -52,6 +60,34 @@ func (h *Handlers) Routes() *chi.Mux {
 			{
 				r.Get("/", h.controllers.User.List)
 				r.Get("/{ID}", h.controllers.User.Show)
+				r.Post("/{ID}/deposits", h.controllers.Session.requireAdmin(h.controllers.Account.Deposit))
+				r.Post("/{ID}/withdrawals", h.controllers.Session.requireAdmin(h.controllers.Account.Withdraw))
+			}
+		})
+
+		r.Route("/account", func(r chi.Router) {
+			r.Use(h.controllers.Session.authenticate)
+			{
+				r.Get("/", h.controllers.Account.Show)
+				r.Get("/transactions", h.controllers.Account.Entries)
+			}
+		})
+
+		r.Route("/categories", func(r chi.Router) {
+			r.Use(h.controllers.Session.authenticate)
+			{
+				r.Get("/", h.controllers.Category.List)
+				r.Get("/{ID}", h.controllers.Category.Show)
+				r.Post("/", h.controllers.Session.requireAdmin(h.controllers.Category.Create))
+				r.Patch("/{ID}", h.controllers.Session.requireAdmin(h.controllers.Category.Update))
+				r.Delete("/{ID}", h.controllers.Session.requireAdmin(h.controllers.Category.Delete))
+			}
+		})
+
+		r.Route("/ws", func(r chi.Router) {
+			r.Use(h.controllers.Session.authenticate)
+			{
+				r.Get("/", h.controllers.WS.Serve)
 			}
 		})
 
@@ -59,13 +95,31 @@ func (h *Handlers) Routes() *chi.Mux {
 			r.Use(h.controllers.Session.authenticate)
 			{
 				r.Get("/", h.controllers.Lot.List)
+				r.Get("/search", h.controllers.Lot.Search)
+				r.Get("/trash", h.controllers.Session.requireAdmin(h.controllers.Lot.Trash))
 				r.Get("/{ID}", h.controllers.Lot.Show)
 				r.Post("/", h.controllers.Lot.Create)
 				r.Patch("/{ID}", h.controllers.Lot.Update)
 				r.Delete("/{ID}", h.controllers.Lot.Delete)
+				r.Post("/{ID}/restore", h.controllers.Session.requireAdmin(h.controllers.Lot.Restore))
+				r.Post("/{ID}/buy", h.controllers.Lot.Buy)
+				r.Post("/{ID}/accept", h.controllers.Lot.Accept)
+				r.Get("/{ID}/events", h.controllers.Event.Stream)
+				r.Get("/{ID}/history", h.controllers.Lot.History)
+				// second-chance offers
+				r.Get("/{ID}/offer", h.controllers.Lot.ShowOffer)
+				r.Post("/{ID}/offer/accept", h.controllers.Lot.AcceptOffer)
+				r.Post("/{ID}/offer/decline", h.controllers.Lot.DeclineOffer)
+				// attachments
+				r.Get("/{ID}/attachments", h.controllers.Attachment.List)
+				r.Post("/{ID}/attachments", h.controllers.Attachment.Create)
+				r.Delete("/{ID}/attachments/{AttachmentID}", h.controllers.Attachment.Delete)
 				// bids
 				r.Get("/{ID}/bids", h.controllers.Bid.List)
 				r.Post("/{ID}/bids", h.controllers.Bid.Create)
+				r.Get("/{ID}/proxy", h.controllers.Bid.ShowProxy)
+				r.Put("/{ID}/proxy", h.controllers.Bid.SetProxy)
+				r.Delete("/{ID}/proxy", h.controllers.Bid.DeleteProxy)
 			}
 		})
 	})
@@ -128,6 +128,20 @@ func (c *SessionController) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// requireAdmin lets only admins through, it has to come after authenticate.
+func (c *SessionController) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+
+		if !user.IsAdmin() {
+			notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
 // List         godoc
 // @Summary     Login user
 // @Description login user
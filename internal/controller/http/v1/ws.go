@@ -0,0 +1,358 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/internal/validator"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	_wsMaxMessageSize = 64 << 10
+	_wsWriteTimeout   = 10 * time.Second
+	_wsOutboxSize     = 64
+)
+
+// Message types of the WebSocket protocol. The client sends subscribe,
+// unsubscribe and bid messages and gets an ack or an error for each of them,
+// events of the subscribed lots come as event messages.
+const (
+	wsSubscribe   = "subscribe"
+	wsUnsubscribe = "unsubscribe"
+	wsBid         = "bid"
+	wsAck         = "ack"
+	wsEvent       = "event"
+	wsError       = "error"
+)
+
+// wsRequest is a message from the client. ID is the client's own reference, it
+// is sent back in the reply.
+type wsRequest struct {
+	Type        string          `json:"type" example:"bid"`
+	ID          string          `json:"id,omitempty" example:"42"`
+	LotID       int64           `json:"lot_id" example:"1"`
+	LastEventID int64           `json:"last_event_id,omitempty"`
+	Bid         *entity.BaseBid `json:"bid,omitempty"`
+}
+
+// wsReply is a message to the client. Status and Error are the status code and
+// the error the same request would get from the REST API.
+type wsReply struct {
+	Type        string           `json:"type"`
+	ID          string           `json:"id,omitempty"`
+	LotID       int64            `json:"lot_id,omitempty"`
+	Bid         *entity.Bid      `json:"bid,omitempty"`
+	CounterBids []*entity.Bid    `json:"counter_bids,omitempty"`
+	Event       *entity.LotEvent `json:"event,omitempty"`
+	Status      int              `json:"status,omitempty"`
+	Error       interface{}      `json:"error,omitempty"`
+}
+
+type WSController struct {
+	bids    BidUseCase
+	events  EventUseCase
+	origins map[string]bool
+}
+
+func NewWSController(bids BidUseCase, events EventUseCase, allowedOrigins []string) *WSController {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return &WSController{bids: bids, events: events, origins: origins}
+}
+
+// Get          godoc
+// @Summary     Bidding channel
+// @Description place bids and receive the events of the subscribed lots over a WebSocket
+// @ID          ws
+// @Tags        bids
+// @Param       Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success     101
+// @Failure     401
+// @Failure     403
+// @Router      /ws [get]
+func (c *WSController) Serve(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{Handshake: c.checkOrigin, Handler: c.serveConn}
+	server.ServeHTTP(w, r)
+}
+
+// checkOrigin lets a web page open the channel only from the API's own origin or
+// one of the allowed ones, the upgrade is refused with 403 Forbidden otherwise.
+// Clients other than browsers don't send an origin and are let through.
+func (c *WSController) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+
+	if u.Host == r.Host || c.origins[origin] {
+		config.Origin = u
+		return nil
+	}
+
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+func (c *WSController) serveConn(ws *websocket.Conn) {
+	// The connection is taken over from the HTTP server, the deadlines it had
+	// set for the upgrade request don't apply any more.
+	ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = _wsMaxMessageSize
+
+	s := &wsSession{
+		c:    c,
+		ws:   ws,
+		r:    ws.Request(),
+		user: contextGetUser(ws.Request()),
+		out:  make(chan *wsReply, _wsOutboxSize),
+		subs: make(map[int64]*wsSubscription),
+		done: make(chan struct{}),
+	}
+
+	s.run()
+}
+
+// wsSession is one WebSocket connection. Requests are handled one at a time in
+// the order they come, the replies and events are written by a single writer.
+// A client which doesn't read fast enough fills up its outbox and is
+// disconnected, it can come back and resume its subscriptions with
+// last_event_id.
+type wsSession struct {
+	c    *WSController
+	ws   *websocket.Conn
+	r    *http.Request
+	user *entity.User
+	out  chan *wsReply
+	subs map[int64]*wsSubscription
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type wsSubscription struct {
+	cancel func()
+	stop   chan struct{}
+}
+
+func (s *wsSession) run() {
+	s.wg.Add(1)
+	go s.write()
+
+	for {
+		var data []byte
+
+		err := websocket.Message.Receive(s.ws, &data)
+		if err != nil {
+			break
+		}
+
+		var req wsRequest
+
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			s.fail(&req, func(w http.ResponseWriter, r *http.Request) { badRequestResponse(w, r, err) })
+			continue
+		}
+
+		s.handle(&req)
+	}
+
+	for lotID := range s.subs {
+		s.unsubscribe(lotID)
+	}
+
+	close(s.done)
+	s.ws.Close()
+	s.wg.Wait()
+}
+
+func (s *wsSession) handle(req *wsRequest) {
+	switch req.Type {
+	case wsSubscribe:
+		s.subscribe(req)
+	case wsUnsubscribe:
+		if _, ok := s.subs[req.LotID]; !ok {
+			s.fail(req, func(w http.ResponseWriter, r *http.Request) { notFoundResponse(w, r) })
+			return
+		}
+		s.unsubscribe(req.LotID)
+		s.send(&wsReply{Type: wsAck, ID: req.ID, LotID: req.LotID})
+	case wsBid:
+		s.bid(req)
+	default:
+		s.fail(req, func(w http.ResponseWriter, r *http.Request) {
+			failedValidationResponse(w, r, map[string]string{"type": "must be subscribe, unsubscribe or bid"})
+		})
+	}
+}
+
+func (s *wsSession) subscribe(req *wsRequest) {
+	backlog, events, cancel, err := s.c.events.Subscribe(req.LotID, req.LastEventID)
+	if err != nil {
+		s.fail(req, func(w http.ResponseWriter, r *http.Request) { bidErrorResponse(w, r, err) })
+		return
+	}
+
+	// Subscribing again resumes from the new last_event_id.
+	if _, ok := s.subs[req.LotID]; ok {
+		s.unsubscribe(req.LotID)
+	}
+
+	sub := &wsSubscription{cancel: cancel, stop: make(chan struct{})}
+	s.subs[req.LotID] = sub
+
+	s.send(&wsReply{Type: wsAck, ID: req.ID, LotID: req.LotID})
+
+	for _, event := range backlog {
+		s.send(&wsReply{Type: wsEvent, LotID: req.LotID, Event: event})
+	}
+
+	s.wg.Add(1)
+	go s.forward(req.LotID, sub, events)
+}
+
+func (s *wsSession) unsubscribe(lotID int64) {
+	sub := s.subs[lotID]
+	delete(s.subs, lotID)
+
+	close(sub.stop)
+	sub.cancel()
+}
+
+// forward passes the events of the lot on to the client until the subscription
+// is cancelled or dropped by the broker.
+func (s *wsSession) forward(lotID int64, sub *wsSubscription, events <-chan *entity.LotEvent) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-sub.stop:
+			return
+		case event, ok := <-events:
+			if !ok {
+				select {
+				case <-sub.stop:
+				default:
+					s.send(&wsReply{
+						Type:   wsError,
+						LotID:  lotID,
+						Status: http.StatusGone,
+						Error:  "the subscription has been dropped, please subscribe again with last_event_id",
+					})
+				}
+				return
+			}
+			s.send(&wsReply{Type: wsEvent, LotID: lotID, Event: event})
+		}
+	}
+}
+
+// bid places the bid the same way BidController.Create does.
+func (s *wsSession) bid(req *wsRequest) {
+	bid := &entity.Bid{
+		LotID:    req.LotID,
+		BidderID: &s.user.ID,
+	}
+
+	if fields := req.Bid; fields != nil {
+		if fields.Amount != nil {
+			bid.Amount = *fields.Amount
+		}
+		if fields.Price != nil {
+			bid.Price = *fields.Price
+		}
+		bid.LastPrice = fields.LastPrice
+	}
+
+	v := validator.New()
+
+	if entity.ValidateBid(v, bid); !v.Valid() {
+		s.fail(req, func(w http.ResponseWriter, r *http.Request) { failedValidationResponse(w, r, v.Errors) })
+		return
+	}
+
+	counters, err := s.c.bids.Create(bid)
+	if err != nil {
+		s.fail(req, func(w http.ResponseWriter, r *http.Request) { bidErrorResponse(w, r, err) })
+		return
+	}
+
+	s.send(&wsReply{Type: wsAck, ID: req.ID, LotID: req.LotID, Bid: bid, CounterBids: counters})
+}
+
+// fail sends the client an error message carrying the response the REST API
+// would give, the respond function writes it.
+func (s *wsSession) fail(req *wsRequest, respond func(w http.ResponseWriter, r *http.Request)) {
+	rec := &wsRecorder{header: make(http.Header)}
+	respond(rec, s.r)
+
+	var body struct {
+		Error interface{} `json:"error"`
+	}
+	json.Unmarshal(rec.body.Bytes(), &body)
+
+	s.send(&wsReply{Type: wsError, ID: req.ID, LotID: req.LotID, Status: rec.status, Error: body.Error})
+}
+
+// send queues the reply for the writer. If the outbox is full the client is too
+// slow and the connection is closed.
+func (s *wsSession) send(reply *wsReply) {
+	select {
+	case s.out <- reply:
+	case <-s.done:
+	default:
+		s.ws.Close()
+	}
+}
+
+func (s *wsSession) write() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case reply := <-s.out:
+			s.ws.SetWriteDeadline(time.Now().Add(_wsWriteTimeout))
+
+			err := websocket.JSON.Send(s.ws, reply)
+			if err != nil {
+				s.ws.Close()
+				return
+			}
+		}
+	}
+}
+
+// wsRecorder captures a response written by the REST error helpers.
+type wsRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *wsRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *wsRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
+
+func (rec *wsRecorder) WriteHeader(status int) {
+	rec.status = status
+}
@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+const (
+	// CashAccountID is the account standing for the money outside the system,
+	// deposits are taken from it and withdrawals are paid into it.
+	CashAccountID = "00000000-0000-0000-0000-000000000000"
+	// PlatformAccountID is the account the commission on the sold lots goes to.
+	PlatformAccountID = "00000000-0000-0000-0000-000000000001"
+)
+
+// LedgerKind is what a ledger transaction has been made for.
+type LedgerKind string
+
+const (
+	LedgerDeposit    LedgerKind = "deposit"
+	LedgerWithdrawal LedgerKind = "withdrawal"
+	LedgerSettlement LedgerKind = "settlement"
+)
+
+// Account type
+// @Description The wallet of a user, Balance is the sum of its ledger entries
+// Held is the part of the balance the bids of the user hold, the rest is
+// Available for new bids and withdrawals.
+type Account struct {
+	ID        string     `json:"id"`
+	UserID    *int64     `json:"user_id"`
+	Balance   int64      `json:"balance"`
+	Held      int64      `json:"held"`
+	Available int64      `json:"available"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// LedgerTransaction type
+// @Description A movement of money between accounts, its entries add up to zero
+type LedgerTransaction struct {
+	ID        int64          `json:"id"`
+	Kind      LedgerKind     `json:"kind"`
+	Reference string         `json:"reference"`
+	CreatedBy *int64         `json:"created_by,omitempty"`
+	Entries   []*LedgerEntry `json:"entries"`
+	CreatedAt *time.Time     `json:"created_at,omitempty"`
+}
+
+// LedgerEntry type
+// @Description One side of a ledger transaction: money in (Amount > 0) or out (Amount < 0) of the account
+type LedgerEntry struct {
+	ID            int64      `json:"id"`
+	TransactionID int64      `json:"transaction_id"`
+	AccountID     string     `json:"account_id"`
+	Kind          LedgerKind `json:"kind"`
+	Reference     string     `json:"reference"`
+	Amount        int64      `json:"amount"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+// Transfer type
+// @Description Money paid into or out of the account of a user
+type Transfer struct {
+	Amount    int64  `json:"amount" example:"100000"`
+	Reference string `json:"reference,omitempty" example:"Bank transfer #123"`
+}
+
+// NewTransfer builds the ledger transaction moving the amount from one account to
+// another.
+func NewTransfer(kind LedgerKind, from, to string, amount int64, reference string, createdBy *int64) *LedgerTransaction {
+	return &LedgerTransaction{
+		Kind:      kind,
+		Reference: reference,
+		CreatedBy: createdBy,
+		Entries: []*LedgerEntry{
+			{AccountID: from, Amount: -amount},
+			{AccountID: to, Amount: amount},
+		},
+	}
+}
+
+// NewSettlement builds the ledger transaction paying for the sold lot: the
+// payer's account is charged the end price, the payee gets it less the platform
+// commission, rounded to the nearest unit. The reference is the same every time
+// for the lot, the ledger won't take a second settlement of it.
+func NewSettlement(lot *Lot, payer, payee string, commission float64) *LedgerTransaction {
+	fee := int64(math.Round(float64(lot.EndPrice) * commission))
+
+	t := &LedgerTransaction{
+		Kind:      LedgerSettlement,
+		Reference: fmt.Sprintf("lot:%d", lot.ID),
+		Entries: []*LedgerEntry{
+			{AccountID: payer, Amount: -lot.EndPrice},
+		},
+	}
+
+	// The ledger takes no empty entries.
+	if fee < lot.EndPrice {
+		t.Entries = append(t.Entries, &LedgerEntry{AccountID: payee, Amount: lot.EndPrice - fee})
+	}
+
+	if fee > 0 {
+		t.Entries = append(t.Entries, &LedgerEntry{AccountID: PlatformAccountID, Amount: fee})
+	}
+
+	return t
+}
+
+func ValidateTransfer(v *validator.Validator, transfer *Transfer) {
+	v.Check(transfer.Amount > 0, "amount", "must be greater than zero")
+	v.Check(len(transfer.Reference) <= 500, "reference", "must not be more than 500 bytes long")
+}
@@ -0,0 +1,90 @@
+package entity
+
+import "testing"
+
+func TestNewSettlement(t *testing.T) {
+	const payer, payee = "payer", "payee"
+
+	tests := []struct {
+		name       string
+		endPrice   int64
+		commission float64
+		want       map[string]int64
+	}{
+		{
+			name:       "no commission",
+			endPrice:   1000,
+			commission: 0,
+			want:       map[string]int64{payer: -1000, payee: 1000},
+		},
+		{
+			name:       "commission",
+			endPrice:   1000,
+			commission: 0.05,
+			want:       map[string]int64{payer: -1000, payee: 950, PlatformAccountID: 50},
+		},
+		{
+			name:       "all of it commission",
+			endPrice:   1000,
+			commission: 1,
+			want:       map[string]int64{payer: -1000, PlatformAccountID: 1000},
+		},
+		{
+			name:       "commission rounded up",
+			endPrice:   999,
+			commission: 0.05,
+			want:       map[string]int64{payer: -999, payee: 949, PlatformAccountID: 50},
+		},
+		{
+			name:       "commission rounded down",
+			endPrice:   1009,
+			commission: 0.05,
+			want:       map[string]int64{payer: -1009, payee: 959, PlatformAccountID: 50},
+		},
+		{
+			name:       "commission not exact in binary",
+			endPrice:   100,
+			commission: 0.29,
+			want:       map[string]int64{payer: -100, payee: 71, PlatformAccountID: 29},
+		},
+		{
+			name:       "commission below one unit",
+			endPrice:   5,
+			commission: 0.05,
+			want:       map[string]int64{payer: -5, payee: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := &Lot{ID: 7, EndPrice: tt.endPrice}
+
+			tx := NewSettlement(lot, payer, payee, tt.commission)
+
+			if tx.Kind != LedgerSettlement || tx.Reference != "lot:7" {
+				t.Errorf("got %s transaction %q, want %s transaction %q", tx.Kind, tx.Reference, LedgerSettlement, "lot:7")
+			}
+
+			got := make(map[string]int64)
+			var sum int64
+			for _, e := range tx.Entries {
+				got[e.AccountID] += e.Amount
+				sum += e.Amount
+			}
+
+			if sum != 0 {
+				t.Errorf("entries add up to %d, want 0", sum)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got entries %v, want %v", got, tt.want)
+			}
+			for account, amount := range tt.want {
+				if got[account] != amount {
+					t.Errorf("got entries %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
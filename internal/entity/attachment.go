@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+// AttachmentTypes are the content types of the files which can be attached to a
+// lot, with the file extension they are stored under.
+var AttachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+// Attachment type
+// @Description A photo or a document attached to a lot, the photos have a thumbnail
+type Attachment struct {
+	ID           int64      `json:"id"`
+	LotID        int64      `json:"lot_id"`
+	Filename     string     `json:"filename"`
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	URL          string     `json:"url"`
+	ThumbnailURL *string    `json:"thumbnail_url,omitempty"`
+	Key          string     `json:"-"`
+	ThumbnailKey *string    `json:"-"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+// Image reports whether the attachment is a photo.
+func (a *Attachment) Image() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+func ValidateAttachment(v *validator.Validator, a *Attachment, maxSize int64) {
+	_, allowed := AttachmentTypes[a.ContentType]
+
+	v.Check(a.Filename != "", "filename", "must be provided")
+	v.Check(len(a.Filename) <= 255, "filename", "must not be more than 255 bytes long")
+	v.Check(allowed, "file", "must be a JPEG, PNG or GIF image or a PDF document")
+	v.Check(a.Size > 0, "file", "must not be empty")
+	v.Check(a.Size <= maxSize, "file", fmt.Sprintf("must not be larger than %d bytes", maxSize))
+}
@@ -1,23 +1,71 @@
 package entity
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ElOtro/auction-go/internal/validator"
 )
 
+// BaseBid type
+type BaseBid struct {
+	Amount    *int64 `json:"amount,omitempty" example:"30000"`
+	Price     *int64 `json:"price,omitempty" example:"160000"`
+	LastPrice *int64 `json:"last_price,omitempty" example:"130000"`
+}
+
 // Bid type
+// Amount is how far the bid moved the price of the lot, on a reverse lot it is
+// the decrease.
 type Bid struct {
 	ID        int64      `json:"id"`
 	Amount    int64      `json:"amount"`
 	Price     int64      `json:"price"`
 	LotID     int64      `json:"lot_id,omitempty"`
 	BidderID  *int64     `json:"bidder_id,omitempty"`
+	Auto      bool       `json:"auto"`
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// EndAt is the end of the auction once the bid has been placed, it moves
+	// when the bid lands within the soft-close window of the lot.
+	EndAt *time.Time `json:"end_at,omitempty"`
+	// LastPrice is the lot price the bidder saw when placing the bid. When set
+	// the bid is rejected with ErrStalePrice if the price has moved since.
+	LastPrice *int64 `json:"-"`
 }
 
+// ValidateBid checks the bid as it was requested by the bidder. Amount and Price
+// are both optional (zero means not provided), but only one of them may be set.
 func ValidateBid(v *validator.Validator, bid *Bid) {
-	v.Check(bid.Amount > 0, "start_price", "must be greater than zero")
+	v.Check(bid.Amount >= 0, "amount", "must not be negative")
+	v.Check(bid.Price >= 0, "price", "must not be negative")
+	v.Check(bid.Amount == 0 || bid.Price == 0, "price", "must not be provided together with amount")
 	v.Check(*bid.BidderID != 0, "bidder_id", "must be provided")
 }
+
+// ValidateBidPrice checks the bid against the current price of the lot: the new
+// price must be at least one step above it (below it for a reverse lot) and the
+// change must be a multiple of the step price.
+func ValidateBidPrice(v *validator.Validator, bid *Bid, lot *Lot, current int64) {
+	if lot.Reverse() {
+		max := current - lot.step()
+
+		v.Check(bid.Price <= max, "price", fmt.Sprintf("must be at most %d", max))
+		v.Check(bid.Price > 0, "price", "must be greater than zero")
+	} else {
+		min := current + lot.step()
+
+		v.Check(bid.Price >= min, "price", fmt.Sprintf("must be at least %d", min))
+	}
+
+	if lot.StepPrice > 0 {
+		v.Check(bid.Amount%lot.StepPrice == 0, "amount", fmt.Sprintf("must be a multiple of the step price %d", lot.StepPrice))
+	}
+}
+
+// ValidateSealedBidPrice checks a bid on a sealed-bid lot. Nobody knows the other
+// bids, so the price only has to reach the start price of the lot.
+func ValidateSealedBidPrice(v *validator.Validator, bid *Bid, lot *Lot) {
+	v.Check(bid.Price > 0, "price", "must be provided")
+	v.Check(bid.Price >= lot.StartPrice, "price", fmt.Sprintf("must be at least %d", lot.StartPrice))
+}
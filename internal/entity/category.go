@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+// SlugRX is the format of a category slug: lowercase words joined by hyphens.
+var SlugRX = regexp.MustCompile("^[a-z0-9]+(?:-[a-z0-9]+)*$")
+
+// BaseCategory type
+// A zero ParentID moves the category to the top level.
+type BaseCategory struct {
+	ParentID *int64 `json:"parent_id,omitempty" example:"1"`
+	Name     string `json:"name" example:"Paintings"`
+	Slug     string `json:"slug" example:"paintings"`
+}
+
+// Category type
+// @Description A category of lots, ActiveLots counts the published lots in it and in its subcategories
+type Category struct {
+	ID         int64      `json:"id"`
+	ParentID   *int64     `json:"parent_id,omitempty"`
+	Name       string     `json:"name"`
+	Slug       string     `json:"slug"`
+	ActiveLots int        `json:"active_lots"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+func ValidateCategory(v *validator.Validator, category *Category) {
+	v.Check(category.Name != "", "name", "must be provided")
+	v.Check(len(category.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(category.Slug != "", "slug", "must be provided")
+	v.Check(len(category.Slug) <= 100, "slug", "must not be more than 100 bytes long")
+	v.Check(validator.Matches(category.Slug, SlugRX), "slug", "must only contain lowercase letters, digits and hyphens")
+
+	if category.ParentID != nil {
+		v.Check(*category.ParentID != category.ID, "parent_id", "must not be the category itself")
+	}
+}
+
+// NormalizeTags trims the tags and brings them to lowercase, dropping the empty
+// ones and the repeats. The result is never nil.
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+func ValidateTags(v *validator.Validator, tags []string) {
+	v.Check(len(tags) <= 10, "tags", "must not contain more than 10 tags")
+
+	for _, tag := range tags {
+		v.Check(len(tag) <= 50, "tags", "must not contain tags more than 50 bytes long")
+	}
+}
@@ -1,10 +1,47 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Define a custom errors
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
 	ErrDuplicateEmail = errors.New("duplicate email")
+	ErrDuplicateSlug  = errors.New("duplicate slug")
+
+	ErrAuctionNotStarted = errors.New("auction not started")
+	ErrAuctionClosed     = errors.New("auction closed")
+	ErrStalePrice        = errors.New("stale price")
+	ErrBuyNowUnavailable = errors.New("buy now unavailable")
+	ErrWrongAuctionType  = errors.New("not supported by the auction type")
+
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrOfferClosed       = errors.New("offer closed")
+
+	ErrUnknownCategory  = errors.New("unknown category")
+	ErrCategoryNotEmpty = errors.New("category has subcategories")
 )
+
+// ValidationError is returned by the use cases when a check that can only be made
+// against the stored state (e.g. the current price of a lot) fails. Errors has
+// the same shape as the errors map of validator.Validator.
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %v", e.Errors)
+}
+
+// PolicyError is returned by the use cases when the user isn't allowed to make a
+// change (e.g. to a lot of another seller), Reason tells why.
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return "not permitted: " + e.Reason
+}
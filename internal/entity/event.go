@@ -0,0 +1,110 @@
+package entity
+
+import "time"
+
+// EventType is the kind of change a lot event reports.
+type EventType string
+
+const (
+	// EventBid is a new bid on the lot.
+	EventBid EventType = "bid"
+	// EventPrice is a new current price of the lot.
+	EventPrice EventType = "price"
+	// EventEndAt is a new end time of the lot, it moves on a late bid.
+	EventEndAt EventType = "end_at"
+	// EventStatus is a new status of the lot.
+	EventStatus EventType = "status"
+)
+
+// LotEvent type
+// @Description A change of a lot pushed to the subscribers of the lot
+// ID is set when the event is published, it grows with every event of the lot.
+type LotEvent struct {
+	ID        int64      `json:"id"`
+	Type      EventType  `json:"type"`
+	LotID     int64      `json:"lot_id"`
+	Bid       *Bid       `json:"bid,omitempty"`
+	Price     *int64     `json:"price,omitempty"`
+	EndAt     *time.Time `json:"end_at,omitempty"`
+	Status    *LotStatus `json:"status,omitempty"`
+	WinnerID  *int64     `json:"winner_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewBidEvent reports the bid.
+func NewBidEvent(bid *Bid) *LotEvent {
+	return &LotEvent{
+		Type:      EventBid,
+		LotID:     bid.LotID,
+		Bid:       bid,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewBidEvents reports new bids on the lot: every bid, the price they leave the
+// lot at and the end time if it has moved. The bids on a sealed-bid lot are kept
+// secret, nothing is reported.
+func NewBidEvents(lot *Lot, bids []*Bid, extended bool) []*LotEvent {
+	if lot.Sealed() {
+		return nil
+	}
+
+	events := []*LotEvent{}
+
+	for _, bid := range bids {
+		events = append(events, NewBidEvent(bid))
+	}
+
+	if len(bids) > 0 {
+		events = append(events, NewPriceEvent(lot.ID, bids[len(bids)-1].Price))
+	}
+
+	if extended {
+		events = append(events, NewEndAtEvent(lot))
+	}
+
+	return events
+}
+
+// NewPriceEvent reports the current price of the lot.
+func NewPriceEvent(lotID, price int64) *LotEvent {
+	return &LotEvent{
+		Type:      EventPrice,
+		LotID:     lotID,
+		Price:     &price,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewEndAtEvent reports the end time of the lot.
+func NewEndAtEvent(lot *Lot) *LotEvent {
+	endAt := lot.EndAt
+
+	return &LotEvent{
+		Type:      EventEndAt,
+		LotID:     lot.ID,
+		EndAt:     &endAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewStatusEvent reports the status of the lot, together with the winner and the
+// end price once it is finished.
+func NewStatusEvent(lot *Lot) *LotEvent {
+	status := lot.Status
+
+	event := &LotEvent{
+		Type:      EventStatus,
+		LotID:     lot.ID,
+		Status:    &status,
+		CreatedAt: time.Now(),
+	}
+
+	if status == LotFinished {
+		price := lot.EndPrice
+		event.Price = &price
+		event.WinnerID = lot.WinnerID
+	}
+
+	return event
+}
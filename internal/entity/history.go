@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+// HistoryKind is what has happened to a lot after the end of the auction.
+type HistoryKind string
+
+const (
+	// HistorySold is the lot going to the winner.
+	HistorySold HistoryKind = "sold"
+	// HistoryPaid is the payment for the lot.
+	HistoryPaid HistoryKind = "paid"
+	// HistoryPaymentFailed is a payment the payer couldn't cover.
+	HistoryPaymentFailed HistoryKind = "payment_failed"
+	// HistoryPaymentOverdue is the winner not paying within the deadline.
+	HistoryPaymentOverdue HistoryKind = "payment_overdue"
+	// HistoryOffered is a second-chance offer made to the next bidder.
+	HistoryOffered HistoryKind = "offered"
+	// HistoryOfferAccepted, HistoryOfferDeclined and HistoryOfferExpired are the
+	// ways a second-chance offer is closed.
+	HistoryOfferAccepted HistoryKind = "offer_accepted"
+	HistoryOfferDeclined HistoryKind = "offer_declined"
+	HistoryOfferExpired  HistoryKind = "offer_expired"
+	// HistoryUnsold is the lot left without a buyer, nobody else can be offered
+	// it.
+	HistoryUnsold HistoryKind = "unsold"
+)
+
+// LotHistory type
+// @Description A step of the sale of a lot after the end of the auction, UserID and Price are the ones the step is about
+type LotHistory struct {
+	ID        int64       `json:"id"`
+	LotID     int64       `json:"lot_id"`
+	Kind      HistoryKind `json:"kind"`
+	UserID    *int64      `json:"user_id,omitempty"`
+	Price     *int64      `json:"price,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ElOtro/auction-go/internal/validator"
 )
@@ -15,49 +17,514 @@ const (
 	LotFinished
 )
 
+// SettlementStatus is how far the payment for a finished lot has got.
+type SettlementStatus string
+
+const (
+	SettlementPending SettlementStatus = "pending"
+	SettlementSettled SettlementStatus = "settled"
+	SettlementFailed  SettlementStatus = "failed"
+	// SettlementOffered means the winner hasn't paid in time and the lot has
+	// been offered to the next bidder.
+	SettlementOffered SettlementStatus = "offered"
+	// SettlementDefaulted means nobody has paid for the lot.
+	SettlementDefaulted SettlementStatus = "defaulted"
+)
+
+// LotType is the kind of auction a lot is sold with.
+type LotType string
+
+const (
+	// LotEnglish is the ascending auction, the highest bid wins.
+	LotEnglish LotType = "english"
+	// LotDutch is the descending auction, the price drops until someone accepts it.
+	LotDutch LotType = "dutch"
+	// LotFirstPrice is the sealed-bid auction, the highest bid wins and pays its
+	// own price.
+	LotFirstPrice LotType = "first_price"
+	// LotVickrey is the sealed-bid auction, the highest bid wins and pays the
+	// second-highest price.
+	LotVickrey LotType = "vickrey"
+	// LotReverse is the procurement auction, the bids go down from the start price
+	// and the lowest bid wins.
+	LotReverse LotType = "reverse"
+)
+
 type BaseLot struct {
-	Title       string     `json:"title" example:"Lot #1"`
-	Description string     `json:"description,omitempty" example:"Some Precious Items"`
-	StartPrice  *int64     `json:"start_price,omitempty" example:"100000"`
-	StepPrice   *int64     `json:"step_price,omitempty" example:"15000"`
-	StartAt     *time.Time `json:"start_at,omitempty" example:"2022-09-09T12:45:00+03:00"`
-	EndAt       *time.Time `json:"end_at,omitempty" example:"2022-09-09T13:45:00+03:00"`
-	Notify      bool       `json:"notify" example:"true"`
+	Type        *LotType `json:"type,omitempty" example:"english"`
+	Title       string   `json:"title" example:"Lot #1"`
+	Description string   `json:"description,omitempty" example:"Some Precious Items"`
+	StartPrice  *int64   `json:"start_price,omitempty" example:"100000"`
+	StepPrice   *int64   `json:"step_price,omitempty" example:"15000"`
+	// ReservePrice is the hidden minimum the seller accepts, it is only shown
+	// to the creator of the lot.
+	ReservePrice *int64     `json:"reserve_price,omitempty" example:"150000"`
+	BuyNowPrice  *int64     `json:"buy_now_price,omitempty" example:"300000"`
+	StartAt      *time.Time `json:"start_at,omitempty" example:"2022-09-09T12:45:00+03:00"`
+	EndAt        *time.Time `json:"end_at,omitempty" example:"2022-09-09T13:45:00+03:00"`
+	SoftClose    *SoftClose `json:"soft_close,omitempty"`
+	Dutch        *Dutch     `json:"dutch,omitempty"`
+	Notify       bool       `json:"notify" example:"true"`
+	CategoryID   *int64     `json:"category_id,omitempty" example:"1"`
+	Tags         []string   `json:"tags,omitempty" example:"oil,portrait"`
+}
+
+// SoftClose type
+// @Description Keep the auction open for Extension minutes after a bid which arrives within the last Window minutes
+type SoftClose struct {
+	Window    int `json:"window" example:"2"`
+	Extension int `json:"extension" example:"5"`
+}
+
+// Dutch type
+// @Description The price drops by the step price every Interval minutes until it reaches FloorPrice
+type Dutch struct {
+	FloorPrice int64 `json:"floor_price" example:"40000"`
+	Interval   int   `json:"interval" example:"10"`
 }
 
 // Lot type
 // @Description Lot
 type Lot struct {
-	ID          int64      `json:"id"`
-	Status      LotStatus  `json:"status"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	StartPrice  int64      `json:"start_price"`
-	EndPrice    int64      `json:"end_price"`
-	StepPrice   int64      `json:"step_price"`
-	CreatorID   *int64     `json:"creator_id"`
-	WinnerID    *int64     `json:"winner_id,omitempty"`
-	StartAt     time.Time  `json:"start_at"`
-	EndAt       time.Time  `json:"end_at"`
-	Notify      bool       `json:"notify"`
-	DestroyedAt *time.Time `json:"-"`
-	CreatedAt   *time.Time `json:"created_at,omitempty"`
-	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	ID          int64     `json:"id"`
+	Status      LotStatus `json:"status"`
+	Type        LotType   `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartPrice  int64     `json:"start_price"`
+	EndPrice    int64     `json:"end_price"`
+	StepPrice   int64     `json:"step_price"`
+	// ReservePrice is cleared before the lot is shown to anyone but its creator,
+	// ReserveMet is the public part of it.
+	ReservePrice     *int64            `json:"reserve_price,omitempty"`
+	ReserveMet       bool              `json:"reserve_met"`
+	BuyNowPrice      *int64            `json:"buy_now_price,omitempty"`
+	CreatorID        *int64            `json:"creator_id"`
+	WinnerID         *int64            `json:"winner_id,omitempty"`
+	SettlementStatus *SettlementStatus `json:"settlement_status,omitempty"`
+	// PaymentDueAt is the time the winner has to pay for the sold lot by.
+	PaymentDueAt *time.Time `json:"payment_due_at,omitempty"`
+	StartAt      time.Time  `json:"start_at"`
+	EndAt        time.Time  `json:"end_at"`
+	SoftClose    *SoftClose `json:"soft_close,omitempty"`
+	Dutch        *Dutch     `json:"dutch,omitempty"`
+	// AskingPrice is the price a Dutch lot can be accepted at right now.
+	AskingPrice *int64        `json:"asking_price,omitempty"`
+	CategoryID  *int64        `json:"category_id,omitempty"`
+	Tags        []string      `json:"tags"`
+	Attachments []*Attachment `json:"attachments,omitempty"`
+	Notify      bool          `json:"notify"`
+	DestroyedAt *time.Time    `json:"destroyed_at,omitempty"`
+	Version     int32         `json:"version"`
+	CreatedAt   *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time    `json:"updated_at,omitempty"`
+	// SearchLanguage is the text search configuration the lot is indexed in.
+	SearchLanguage string `json:"-"`
 }
 
 // LotSearch  type
+// @Description A lot found by the search, Title and Snippet (a part of the description) are HTML-escaped and their matching words are wrapped in <mark> tags
 type LotSearch struct {
-	ID    int64  `json:"id"`
-	Title string `json:"title"`
+	ID      int64     `json:"id"`
+	Status  LotStatus `json:"status"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet"`
+	Rank    float32   `json:"rank"`
+}
+
+// LotSearchQuery type
+// Query is a web search query (quoted phrases, "or", "-" to leave a word out);
+// with Prefix the last word matches every word it begins, as the user types.
+type LotSearchQuery struct {
+	Query  string
+	Prefix bool
+	Filters
+}
+
+func ValidateLotSearchQuery(v *validator.Validator, q LotSearchQuery) {
+	v.Check(strings.TrimSpace(q.Query) != "", "q", "must be provided")
+	v.Check(len(q.Query) <= 500, "q", "must not be more than 500 bytes long")
+
+	if q.Prefix {
+		v.Check(len(q.Words()) > 0, "q", "must contain a word")
+	}
+
+	ValidateFilters(v, q.Filters)
+}
+
+// Words returns the words of the query, without any operators.
+func (q LotSearchQuery) Words() []string {
+	return strings.FieldsFunc(q.Query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
 }
 
+// PrefixQuery returns the query in the to_tsquery syntax with the last word
+// matched as a prefix: "big red ca" becomes "big & red & ca:*".
+func (q LotSearchQuery) PrefixQuery() string {
+	words := q.Words()
+	if len(words) == 0 {
+		return ""
+	}
+
+	return strings.Join(words, " & ") + ":*"
+}
+
+// LotFilters type
+// Every filter is optional: Title matches a part of the title, CategoryID takes
+// the lots of the category and its subcategories, the price range is on the
+// start price and the windows take the lots starting (ending) within them.
 type LotFilters struct {
-	Title string
+	Title      string
+	Status     *LotStatus
+	CreatorID  *int64
+	CategoryID *int64
+	Tag        string
+	MinPrice   *int64
+	MaxPrice   *int64
+	StartFrom  *time.Time
+	StartTo    *time.Time
+	EndFrom    *time.Time
+	EndTo      *time.Time
+	Filters
+}
+
+func ValidateLotFilters(v *validator.Validator, f LotFilters) {
+	if f.Status != nil {
+		status := *f.Status
+		v.Check(status == LotPending || status == LotPublished || status == LotProcessing || status == LotFinished, "status", "must be 1, 2, 4 or 8")
+	}
+
+	if f.MinPrice != nil && f.MaxPrice != nil {
+		v.Check(*f.MinPrice <= *f.MaxPrice, "max_price", "must not be less than min price")
+	}
+
+	if f.StartFrom != nil && f.StartTo != nil {
+		v.Check(!f.StartTo.Before(*f.StartFrom), "start_to", "must not be before start from")
+	}
+
+	if f.EndFrom != nil && f.EndTo != nil {
+		v.Check(!f.EndTo.Before(*f.EndFrom), "end_to", "must not be before end from")
+	}
+
+	v.Check(len(f.Tag) <= 50, "tag", "must not be more than 50 bytes long")
+
+	ValidateFilters(v, f.Filters)
+}
+
+// Biddable checks whether a bid can be placed on the lot at the given time.
+func (l *Lot) Biddable(now time.Time) error {
+	switch {
+	case l.DestroyedAt != nil:
+		return ErrRecordNotFound
+	case l.Status == LotPending || now.Before(l.StartAt):
+		return ErrAuctionNotStarted
+	case l.Status != LotPublished || !now.Before(l.EndAt):
+		return ErrAuctionClosed
+	}
+
+	return nil
+}
+
+// BiddingStarted reports whether the auction for the lot has opened by the given
+// time, whether or not anybody has bid yet.
+func (l *Lot) BiddingStarted(now time.Time) bool {
+	return l.Status != LotPending || !now.Before(l.StartAt)
+}
+
+// Sealed reports whether the bids on the lot are kept secret until the end of
+// the auction.
+func (l *Lot) Sealed() bool {
+	return l.Type == LotFirstPrice || l.Type == LotVickrey
+}
+
+// Reverse reports whether the price of the lot goes down with every bid and the
+// lowest bid wins.
+func (l *Lot) Reverse() bool {
+	return l.Type == LotReverse
+}
+
+// BidderPays reports whether the winning bidder pays for the lot, so that every
+// bid holds the funds for it. On a reverse lot the bidder is the one who gets
+// paid.
+func (l *Lot) BidderPays() bool {
+	return !l.Reverse()
+}
+
+// BidsHidden reports whether the bidders can only see their own bids on the lot
+// at the given time.
+func (l *Lot) BidsHidden(now time.Time) bool {
+	return l.Sealed() && l.Status != LotFinished && now.Before(l.EndAt)
+}
+
+// Finish closes the lot with the leading bid (nil if there are none) as the
+// winner. If the bid hasn't reached the reserve price (for a reverse lot, is
+// above it) the lot is finished without a winner. The winner of a Vickrey lot
+// pays the price of the runner-up bid (nil if there is none), but no less than
+// the start and reserve prices.
+func (l *Lot) Finish(top, runnerUp *Bid) {
+	l.Status = LotFinished
+	l.WinnerID = nil
+	l.EndPrice = 0
+	l.SettlementStatus = nil
+
+	if top == nil || (l.ReservePrice != nil && !l.ReserveReached(top.Price)) {
+		return
+	}
+
+	l.WinnerID = top.BidderID
+	l.EndPrice = top.Price
+	l.settle()
+
+	if l.Type != LotVickrey {
+		return
+	}
+
+	l.EndPrice = l.StartPrice
+	if runnerUp != nil && runnerUp.Price > l.EndPrice {
+		l.EndPrice = runnerUp.Price
+	}
+	if l.ReservePrice != nil && *l.ReservePrice > l.EndPrice {
+		l.EndPrice = *l.ReservePrice
+	}
+}
+
+// Sell closes the lot at a fixed price (the buy-now price or the asking price
+// of a Dutch lot) with the buyer as the winner. The given bid carries the price
+// and is filled in as the final bid on top of the leading one (nil if there are
+// none). A sale below the current price, as the asking price of a Dutch lot is
+// once it has dropped, raises nothing.
+func (l *Lot) Sell(top *Bid, bid *Bid) {
+	price := l.StartPrice
+	if top != nil {
+		price = top.Price
+	}
+
+	bid.LotID = l.ID
+	bid.Amount = bid.Price - price
+	if bid.Amount < 0 {
+		bid.Amount = 0
+	}
+
+	l.Status = LotFinished
+	l.WinnerID = bid.BidderID
+	l.EndPrice = bid.Price
+	l.settle()
+}
+
+// settle marks the sold lot as waiting for the payment.
+func (l *Lot) settle() {
+	status := SettlementPending
+	l.SettlementStatus = &status
+}
+
+// AwaitingPayment reports whether the sold lot is waiting for the winner to pay.
+func (l *Lot) AwaitingPayment() bool {
+	if l.SettlementStatus == nil {
+		return false
+	}
+
+	status := *l.SettlementStatus
+	return status == SettlementPending || status == SettlementFailed
+}
+
+// PaymentOverdue reports whether the winner hasn't paid within the deadline after
+// the end of the auction.
+func (l *Lot) PaymentOverdue(now time.Time, deadline time.Duration) bool {
+	return l.AwaitingPayment() && !now.Before(l.EndAt.Add(deadline))
+}
+
+// Award makes the bidder of the accepted second-chance offer the winner of the
+// lot at the price of the offer.
+func (l *Lot) Award(o *Offer) {
+	o.Status = OfferAccepted
+	l.WinnerID = o.BidderID
+	l.EndPrice = o.Price
+}
+
+// Offer makes the second-chance offer of the lot to the bidder of the given bid
+// (nil if there is nobody left to offer it to) at the price of the bid. If there
+// is no bid or it hasn't reached the reserve price the lot is left without a
+// buyer and nil is returned.
+func (l *Lot) Offer(bid *Bid, expiresAt time.Time) *Offer {
+	status := SettlementDefaulted
+	l.SettlementStatus = &status
+
+	if bid == nil || (l.ReservePrice != nil && !l.ReserveReached(bid.Price)) {
+		return nil
+	}
+
+	status = SettlementOffered
+
+	return &Offer{
+		LotID:     l.ID,
+		BidderID:  bid.BidderID,
+		Price:     bid.Price,
+		Status:    OfferOpen,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Parties returns who pays for the sold lot and who gets paid: the winner pays
+// the creator, on a reverse lot the other way round.
+func (l *Lot) Parties() (payer, payee int64) {
+	if l.Reverse() {
+		return *l.CreatorID, *l.WinnerID
+	}
+	return *l.WinnerID, *l.CreatorID
+}
+
+// BuyNowAvailable reports whether the lot can still be bought at its buy-now
+// price: the option goes away once the leading bid (nil if there are none)
+// reaches the given share of the buy-now price.
+func (l *Lot) BuyNowAvailable(top *Bid, share float64) bool {
+	if l.BuyNowPrice == nil {
+		return false
+	}
+
+	return top == nil || float64(top.Price) < share*float64(*l.BuyNowPrice)
+}
+
+// Extend keeps the auction open for the soft-close extension after a bid placed
+// at the given time if it lands within the soft-close window. The extensions
+// don't stack, the end is never more than one extension after the last bid. It
+// reports whether the end time has changed.
+func (l *Lot) Extend(at time.Time) bool {
+	if l.SoftClose == nil {
+		return false
+	}
+
+	window := time.Duration(l.SoftClose.Window) * time.Minute
+	if at.Before(l.EndAt.Add(-window)) {
+		return false
+	}
+
+	endAt := at.Add(time.Duration(l.SoftClose.Extension) * time.Minute)
+	if !endAt.After(l.EndAt) {
+		return false
+	}
+
+	l.EndAt = endAt
+
+	return true
+}
+
+// DutchPrice returns the asking price of a Dutch lot at the given time: the
+// start price goes down by the step price every interval, but never below the
+// floor price.
+func (l *Lot) DutchPrice(at time.Time) int64 {
+	if l.Dutch == nil || l.Dutch.Interval <= 0 || at.Before(l.StartAt) {
+		return l.StartPrice
+	}
+
+	drops := int64(at.Sub(l.StartAt) / (time.Duration(l.Dutch.Interval) * time.Minute))
+
+	price := l.StartPrice - drops*l.StepPrice
+	if price < l.Dutch.FloorPrice {
+		price = l.Dutch.FloorPrice
+	}
+
+	return price
+}
+
+// ReserveReached reports whether the price reaches the reserve price of the lot.
+func (l *Lot) ReserveReached(price int64) bool {
+	if l.Reverse() {
+		return price <= *l.ReservePrice
+	}
+	return price >= *l.ReservePrice
+}
+
+// HideReserve clears the reserve price unless the user is the creator of the lot.
+// Whether the reserve has been met is not shown for a sealed lot before it is
+// finished, it would give away the bids.
+func (l *Lot) HideReserve(userID int64) {
+	if l.CreatorID == nil || *l.CreatorID != userID {
+		l.ReservePrice = nil
+	}
+
+	if l.Sealed() && l.Status != LotFinished {
+		l.ReserveMet = false
+	}
+}
+
+// step is the minimal raise of the price, one unit if the lot has no step price.
+func (l *Lot) step() int64 {
+	if l.StepPrice > 0 {
+		return l.StepPrice
+	}
+	return 1
 }
 
 func ValidateLot(v *validator.Validator, lot *Lot) {
+	v.Check(validator.In(string(lot.Type), string(LotEnglish), string(LotDutch), string(LotFirstPrice), string(LotVickrey), string(LotReverse)), "type", "must be english, dutch, first_price, vickrey or reverse")
 	v.Check(lot.Title != "", "title", "must be provided")
 	v.Check(lot.Description != "", "description", "must be provided")
 	v.Check(lot.StartPrice > 0, "start_price", "must be greater than zero")
 	v.Check(*lot.CreatorID != 0, "creator_id", "must be provided")
+	ValidateTags(v, lot.Tags)
+
+	if lot.ReservePrice != nil && !lot.Reverse() {
+		v.Check(*lot.ReservePrice >= lot.StartPrice, "reserve_price", "must not be less than start price")
+	}
+
+	if lot.SoftClose != nil {
+		v.Check(lot.SoftClose.Window > 0, "soft_close", "window must be greater than zero")
+		v.Check(lot.SoftClose.Extension > 0, "soft_close", "extension must be greater than zero")
+	}
+
+	if lot.BuyNowPrice != nil {
+		v.Check(*lot.BuyNowPrice > lot.StartPrice, "buy_now_price", "must be greater than start price")
+		if lot.ReservePrice != nil {
+			v.Check(*lot.BuyNowPrice >= *lot.ReservePrice, "buy_now_price", "must not be less than reserve price")
+		}
+	}
+
+	switch lot.Type {
+	case LotDutch:
+		validateDutch(v, lot)
+	case LotFirstPrice, LotVickrey:
+		validateSealed(v, lot)
+	case LotReverse:
+		validateReverse(v, lot)
+	default:
+		v.Check(lot.Dutch == nil, "dutch", "must only be provided for a dutch auction")
+	}
+}
+
+// validateDutch checks the settings only a Dutch lot has and the ones it can't
+// have.
+func validateDutch(v *validator.Validator, lot *Lot) {
+	if lot.Dutch == nil {
+		v.AddError("dutch", "must be provided")
+		return
+	}
+
+	v.Check(lot.Dutch.FloorPrice > 0, "dutch", "floor price must be greater than zero")
+	v.Check(lot.Dutch.FloorPrice < lot.StartPrice, "dutch", "floor price must be less than start price")
+	v.Check(lot.Dutch.Interval > 0, "dutch", "interval must be greater than zero")
+	v.Check(lot.StepPrice > 0, "step_price", "must be greater than zero")
+	v.Check(lot.ReservePrice == nil, "reserve_price", "must not be provided for a dutch auction")
+	v.Check(lot.BuyNowPrice == nil, "buy_now_price", "must not be provided for a dutch auction")
+	v.Check(lot.SoftClose == nil, "soft_close", "must not be provided for a dutch auction")
+}
+
+// validateSealed checks the settings a sealed-bid lot can't have: both would
+// give away the bids.
+func validateSealed(v *validator.Validator, lot *Lot) {
+	v.Check(lot.Dutch == nil, "dutch", "must only be provided for a dutch auction")
+	v.Check(lot.BuyNowPrice == nil, "buy_now_price", "must not be provided for a sealed-bid auction")
+	v.Check(lot.SoftClose == nil, "soft_close", "must not be provided for a sealed-bid auction")
+}
+
+// validateReverse checks the prices of a reverse lot, they work the other way
+// round: the reserve is the highest price the buyer accepts.
+func validateReverse(v *validator.Validator, lot *Lot) {
+	v.Check(lot.Dutch == nil, "dutch", "must only be provided for a dutch auction")
+	v.Check(lot.BuyNowPrice == nil, "buy_now_price", "must not be provided for a reverse auction")
+
+	if lot.ReservePrice != nil {
+		v.Check(*lot.ReservePrice > 0, "reserve_price", "must be greater than zero")
+		v.Check(*lot.ReservePrice <= lot.StartPrice, "reserve_price", "must not be greater than start price")
+	}
 }
@@ -0,0 +1,266 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLotExtend(t *testing.T) {
+	endAt := time.Date(2022, 9, 9, 13, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		softClose *SoftClose
+		at        time.Time
+		want      time.Time
+		extended  bool
+	}{
+		{
+			name: "no soft close",
+			at:   endAt.Add(-time.Minute),
+			want: endAt,
+		},
+		{
+			name:      "bid before the window",
+			softClose: &SoftClose{Window: 2, Extension: 5},
+			at:        endAt.Add(-3 * time.Minute),
+			want:      endAt,
+		},
+		{
+			name:      "bid within the window",
+			softClose: &SoftClose{Window: 2, Extension: 5},
+			at:        endAt.Add(-time.Minute),
+			want:      endAt.Add(4 * time.Minute),
+			extended:  true,
+		},
+		{
+			name:      "extension shorter than the time left",
+			softClose: &SoftClose{Window: 5, Extension: 2},
+			at:        endAt.Add(-4 * time.Minute),
+			want:      endAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := &Lot{EndAt: endAt, SoftClose: tt.softClose}
+
+			extended := lot.Extend(tt.at)
+
+			if extended != tt.extended || !lot.EndAt.Equal(tt.want) {
+				t.Errorf("got end %v (extended %v), want %v (extended %v)", lot.EndAt, extended, tt.want, tt.extended)
+			}
+		})
+	}
+
+	// Bids within the window one after another keep the end one extension after
+	// the last of them.
+	lot := &Lot{EndAt: endAt, SoftClose: &SoftClose{Window: 2, Extension: 5}}
+
+	for i := 0; i < 3; i++ {
+		lot.Extend(endAt.Add(-time.Minute))
+	}
+
+	if want := endAt.Add(4 * time.Minute); !lot.EndAt.Equal(want) {
+		t.Errorf("got end %v after repeated bids, want %v", lot.EndAt, want)
+	}
+
+	// A later bid within the extended window extends from its own time, not
+	// from the end the earlier bid set.
+	lot = &Lot{EndAt: endAt, SoftClose: &SoftClose{Window: 2, Extension: 5}}
+
+	lot.Extend(endAt.Add(-2 * time.Minute))
+	lot.Extend(endAt.Add(2 * time.Minute))
+
+	if want := endAt.Add(7 * time.Minute); !lot.EndAt.Equal(want) {
+		t.Errorf("got end %v after bids at different times, want %v", lot.EndAt, want)
+	}
+}
+
+func TestLotFinish(t *testing.T) {
+	winner, runnerUp := int64(1), int64(2)
+
+	bid := func(bidderID *int64, price int64) *Bid { return &Bid{BidderID: bidderID, Price: price} }
+	price := func(p int64) *int64 { return &p }
+
+	tests := []struct {
+		name     string
+		lot      Lot
+		top      *Bid
+		runnerUp *Bid
+		winner   *int64
+		endPrice int64
+	}{
+		{
+			name: "no bids",
+			lot:  Lot{Type: LotFirstPrice, StartPrice: 100},
+		},
+		{
+			name:     "first price single bid",
+			lot:      Lot{Type: LotFirstPrice, StartPrice: 100},
+			top:      bid(&winner, 300),
+			winner:   &winner,
+			endPrice: 300,
+		},
+		{
+			name:     "first price pays its own bid",
+			lot:      Lot{Type: LotFirstPrice, StartPrice: 100},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 200),
+			winner:   &winner,
+			endPrice: 300,
+		},
+		{
+			name:     "first price tie",
+			lot:      Lot{Type: LotFirstPrice, StartPrice: 100},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 300),
+			winner:   &winner,
+			endPrice: 300,
+		},
+		{
+			name: "first price below the reserve",
+			lot:  Lot{Type: LotFirstPrice, StartPrice: 100, ReservePrice: price(400)},
+			top:  bid(&winner, 300),
+		},
+		{
+			name:     "vickrey single bid pays the start price",
+			lot:      Lot{Type: LotVickrey, StartPrice: 100},
+			top:      bid(&winner, 300),
+			winner:   &winner,
+			endPrice: 100,
+		},
+		{
+			name:     "vickrey pays the second price",
+			lot:      Lot{Type: LotVickrey, StartPrice: 100},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 200),
+			winner:   &winner,
+			endPrice: 200,
+		},
+		{
+			name:     "vickrey tie",
+			lot:      Lot{Type: LotVickrey, StartPrice: 100},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 300),
+			winner:   &winner,
+			endPrice: 300,
+		},
+		{
+			name:     "vickrey second price below the reserve",
+			lot:      Lot{Type: LotVickrey, StartPrice: 100, ReservePrice: price(250)},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 200),
+			winner:   &winner,
+			endPrice: 250,
+		},
+		{
+			name:     "vickrey top bid below the reserve",
+			lot:      Lot{Type: LotVickrey, StartPrice: 100, ReservePrice: price(400)},
+			top:      bid(&winner, 300),
+			runnerUp: bid(&runnerUp, 200),
+		},
+		{
+			name:     "reverse bid under the reserve",
+			lot:      Lot{Type: LotReverse, StartPrice: 1000, ReservePrice: price(800)},
+			top:      bid(&winner, 700),
+			winner:   &winner,
+			endPrice: 700,
+		},
+		{
+			name: "reverse bid above the reserve",
+			lot:  Lot{Type: LotReverse, StartPrice: 1000, ReservePrice: price(800)},
+			top:  bid(&winner, 900),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := tt.lot
+
+			lot.Finish(tt.top, tt.runnerUp)
+
+			if lot.Status != LotFinished {
+				t.Errorf("got status %d, want %d", lot.Status, LotFinished)
+			}
+
+			if tt.winner == nil {
+				if lot.WinnerID != nil || lot.EndPrice != 0 || lot.SettlementStatus != nil {
+					t.Errorf("got winner %v at %d, want none", lot.WinnerID, lot.EndPrice)
+				}
+				return
+			}
+
+			if lot.WinnerID == nil || *lot.WinnerID != *tt.winner || lot.EndPrice != tt.endPrice {
+				t.Errorf("got winner %v at %d, want %d at %d", lot.WinnerID, lot.EndPrice, *tt.winner, tt.endPrice)
+			}
+
+			if !lot.AwaitingPayment() {
+				t.Errorf("sold lot isn't waiting for the payment")
+			}
+		})
+	}
+}
+
+func TestLotDutchPrice(t *testing.T) {
+	startAt := time.Date(2022, 9, 9, 12, 0, 0, 0, time.UTC)
+
+	lot := &Lot{
+		Type:       LotDutch,
+		StartPrice: 1000,
+		StepPrice:  100,
+		StartAt:    startAt,
+		EndAt:      startAt.Add(2 * time.Hour),
+		Dutch:      &Dutch{FloorPrice: 400, Interval: 10},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int64
+	}{
+		{
+			name: "before the start",
+			at:   startAt.Add(-time.Hour),
+			want: 1000,
+		},
+		{
+			name: "at the start",
+			at:   startAt,
+			want: 1000,
+		},
+		{
+			name: "within the first interval",
+			at:   startAt.Add(9 * time.Minute),
+			want: 1000,
+		},
+		{
+			name: "after two intervals",
+			at:   startAt.Add(25 * time.Minute),
+			want: 800,
+		},
+		{
+			name: "down to the floor",
+			at:   startAt.Add(60 * time.Minute),
+			want: 400,
+		},
+		{
+			name: "clamped to the floor",
+			at:   startAt.Add(90 * time.Minute),
+			want: 400,
+		},
+		{
+			name: "after the end",
+			at:   startAt.Add(3 * time.Hour),
+			want: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lot.DutchPrice(tt.at); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// OfferStatus is where a second-chance offer stands.
+type OfferStatus string
+
+const (
+	OfferOpen     OfferStatus = "open"
+	OfferAccepted OfferStatus = "accepted"
+	OfferDeclined OfferStatus = "declined"
+	OfferExpired  OfferStatus = "expired"
+)
+
+// Offer type
+// @Description A second-chance offer of a lot the winner hasn't paid for, made to the next bidder at the price of their bid
+type Offer struct {
+	ID        int64       `json:"id"`
+	LotID     int64       `json:"lot_id"`
+	BidderID  *int64      `json:"bidder_id"`
+	Price     int64       `json:"price"`
+	Status    OfferStatus `json:"status"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	CreatedAt *time.Time  `json:"created_at,omitempty"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+}
+
+// Open reports whether the offer can still be accepted or declined at the given
+// time.
+func (o *Offer) Open(now time.Time) bool {
+	return o.Status == OfferOpen && now.Before(o.ExpiresAt)
+}
@@ -0,0 +1,139 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/validator"
+)
+
+// ProxyBid type
+// @Description The ceiling up to which the system bids on behalf of the bidder
+type ProxyBid struct {
+	ID        int64      `json:"id"`
+	MaxPrice  int64      `json:"max_price"`
+	LotID     int64      `json:"lot_id"`
+	BidderID  *int64     `json:"bidder_id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func ValidateProxyBid(v *validator.Validator, proxy *ProxyBid) {
+	v.Check(proxy.MaxPrice > 0, "max_price", "must be greater than zero")
+	v.Check(*proxy.BidderID != 0, "bidder_id", "must be provided")
+}
+
+// ValidateProxyBidPrice checks the maximum against the leading bid of the lot
+// (nil if there are none): the leader may not go under the current price and
+// anybody else has to be able to beat it by at least one step.
+func ValidateProxyBidPrice(v *validator.Validator, proxy *ProxyBid, lot *Lot, top *Bid) {
+	min := lot.StartPrice + lot.step()
+	if top != nil {
+		min = top.Price + lot.step()
+		if *top.BidderID == *proxy.BidderID {
+			min = top.Price
+		}
+	}
+
+	v.Check(proxy.MaxPrice >= min, "max_price", fmt.Sprintf("must be at least %d", min))
+}
+
+// CounterBids works out how the proxy bids answer the leading bid of the lot (nil
+// if there are none). The proxies must be ordered the way they compete: by the
+// maximum, highest first, and by the time they were set, earliest first.
+//
+// The strongest proxy of someone other than the leader is the challenger. If the
+// leader's own proxy ranks above it, the leader answers one step above the
+// challenger's maximum (capped at their own maximum); otherwise the challenger
+// takes the lead one step above the runner-up. The runner-up, or the challenger
+// when it loses, is pushed to its maximum first so that every raise is on
+// record. Either way the price never goes higher than needed, and when two
+// maximums are equal the earliest proxy keeps the lot at that price.
+func CounterBids(lot *Lot, top *Bid, proxies []*ProxyBid) []*Bid {
+	step := lot.step()
+
+	price := lot.StartPrice
+	var leader int64
+	if top != nil {
+		price = top.Price
+		leader = *top.BidderID
+	}
+
+	// Rank of the leader's proxy in the list, -1 if there is none.
+	leaderRank := -1
+	challengers := []*ProxyBid{}
+	var challengerRank int
+
+	for i, proxy := range proxies {
+		switch {
+		case *proxy.BidderID == leader:
+			leaderRank = i
+		case proxy.MaxPrice >= price+step:
+			if len(challengers) == 0 {
+				challengerRank = i
+			}
+			challengers = append(challengers, proxy)
+		}
+	}
+
+	if len(challengers) == 0 {
+		return nil
+	}
+
+	challenger := challengers[0]
+
+	bids := []*Bid{}
+	place := func(proxy *ProxyBid, to int64) {
+		bids = append(bids, &Bid{
+			Amount:   to - price,
+			Price:    to,
+			LotID:    lot.ID,
+			BidderID: proxy.BidderID,
+			Auto:     true,
+		})
+		price = to
+	}
+
+	// The leader's proxy defends the lead.
+	if leaderRank >= 0 && leaderRank < challengerRank {
+		defender := proxies[leaderRank]
+
+		to := min64(defender.MaxPrice, challenger.MaxPrice+step)
+		if challenger.MaxPrice < to {
+			place(challenger, challenger.MaxPrice)
+		}
+		place(defender, to)
+
+		return bids
+	}
+
+	// The challenger takes the lead, the runner-up is whoever can go highest
+	// among the rest.
+	var runner *ProxyBid
+	runnerMax := price
+
+	if leaderRank >= 0 && proxies[leaderRank].MaxPrice >= price+step {
+		runner = proxies[leaderRank]
+		runnerMax = runner.MaxPrice
+	}
+
+	if len(challengers) > 1 && challengers[1].MaxPrice > runnerMax {
+		runner = challengers[1]
+		runnerMax = runner.MaxPrice
+	}
+
+	to := min64(challenger.MaxPrice, runnerMax+step)
+	if runner != nil && runnerMax < to {
+		place(runner, runnerMax)
+	}
+	place(challenger, to)
+
+	return bids
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
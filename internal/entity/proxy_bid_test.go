@@ -0,0 +1,129 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestCounterBids(t *testing.T) {
+	type want struct {
+		bidder int64
+		price  int64
+	}
+
+	proxy := func(bidder, max int64) *ProxyBid {
+		return &ProxyBid{MaxPrice: max, BidderID: &bidder}
+	}
+
+	top := func(bidder, price int64) *Bid {
+		return &Bid{Price: price, BidderID: &bidder}
+	}
+
+	tests := []struct {
+		name    string
+		top     *Bid
+		proxies []*ProxyBid
+		want    []want
+	}{
+		{
+			name: "no proxies",
+			top:  top(1, 200),
+		},
+		{
+			name:    "only the leader's proxy",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(1, 500)},
+		},
+		{
+			name:    "proxy under one step above the price",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 205)},
+		},
+		{
+			name:    "first bid on the lot",
+			proxies: []*ProxyBid{proxy(2, 500)},
+			want:    []want{{2, 110}},
+		},
+		{
+			name:    "challenger takes the lead one step above the price",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 500)},
+			want:    []want{{2, 210}},
+		},
+		{
+			name:    "defending proxy answers one step above the challenger",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(1, 500), proxy(2, 300)},
+			want:    []want{{2, 300}, {1, 310}},
+		},
+		{
+			name:    "defending proxy is capped at its maximum",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(1, 305), proxy(2, 300)},
+			want:    []want{{2, 300}, {1, 305}},
+		},
+		{
+			name:    "tie goes to the defending proxy set first",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(1, 300), proxy(2, 300)},
+			want:    []want{{1, 300}},
+		},
+		{
+			name:    "tie goes to the challenger set first",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 300), proxy(1, 300)},
+			want:    []want{{2, 300}},
+		},
+		{
+			name:    "challenger beats the leader's proxy by one step",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 500), proxy(1, 300)},
+			want:    []want{{1, 300}, {2, 310}},
+		},
+		{
+			name:    "runner-up plus step",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 500), proxy(3, 350)},
+			want:    []want{{3, 350}, {2, 360}},
+		},
+		{
+			name:    "runner-up plus step is capped at the maximum",
+			top:     top(1, 200),
+			proxies: []*ProxyBid{proxy(2, 355), proxy(3, 350)},
+			want:    []want{{3, 350}, {2, 355}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := &Lot{ID: 1, StartPrice: 100, StepPrice: 10}
+
+			bids := CounterBids(lot, tt.top, tt.proxies)
+
+			if len(bids) != len(tt.want) {
+				t.Fatalf("got %d bids, want %d", len(bids), len(tt.want))
+			}
+
+			price := lot.StartPrice
+			if tt.top != nil {
+				price = tt.top.Price
+			}
+
+			for i, bid := range bids {
+				if *bid.BidderID != tt.want[i].bidder || bid.Price != tt.want[i].price {
+					t.Errorf("bid %d: bidder %d at %d, want bidder %d at %d",
+						i, *bid.BidderID, bid.Price, tt.want[i].bidder, tt.want[i].price)
+				}
+
+				if bid.Amount != bid.Price-price {
+					t.Errorf("bid %d: amount %d, want %d", i, bid.Amount, bid.Price-price)
+				}
+
+				if !bid.Auto || bid.LotID != lot.ID {
+					t.Errorf("bid %d: auto %v on lot %d, want an automatic bid on lot %d", i, bid.Auto, bid.LotID, lot.ID)
+				}
+
+				price = bid.Price
+			}
+		})
+	}
+}
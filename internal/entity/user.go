@@ -8,6 +8,11 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RoleUser  = 1
+	RoleAdmin = 2
+)
+
 // User type
 type User struct {
 	ID          int64      `json:"id"`
@@ -21,6 +26,11 @@ type User struct {
 	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
 }
 
+// IsAdmin reports whether the user may manage the accounts of other users.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // Create a custom password type
 type password struct {
 	Plaintext *string
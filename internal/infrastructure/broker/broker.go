@@ -0,0 +1,168 @@
+// Package broker implements the in-process broker of lot events.
+package broker
+
+import (
+	"sync"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+const (
+	_defaultHistorySize = 100
+	_subscriberBuffer   = 16
+)
+
+// Broker - fans the events of a lot out to its local subscribers. The last
+// events of every open lot are kept, so a subscriber coming back after a dropped
+// connection can pick up where it left off; they are let go once the lot is
+// finished and nobody is subscribed to it. A subscriber too slow to keep up is
+// dropped rather than holding up the publisher, it is expected to resume the
+// same way.
+type Broker struct {
+	mu          sync.Mutex
+	historySize int
+	lots        map[int64]*topic
+	closed      bool
+}
+
+type topic struct {
+	seq      int64
+	history  []*entity.LotEvent
+	subs     map[chan *entity.LotEvent]struct{}
+	finished bool
+}
+
+// New -.
+func New(historySize int) *Broker {
+	if historySize <= 0 {
+		historySize = _defaultHistorySize
+	}
+
+	return &Broker{
+		historySize: historySize,
+		lots:        make(map[int64]*topic),
+	}
+}
+
+// Publish - sending the event to the subscribers of its lot. An event which
+// comes without an id is numbered by the broker after the highest id seen so
+// far, an event which comes late doesn't take the numbering back.
+func (b *Broker) Publish(event *entity.LotEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	t := b.topic(event.LotID)
+
+	if event.ID == 0 {
+		event.ID = t.seq + 1
+	}
+	if event.ID > t.seq {
+		t.seq = event.ID
+	}
+
+	t.history = append(t.history, event)
+	if len(t.history) > b.historySize {
+		t.history = t.history[len(t.history)-b.historySize:]
+	}
+
+	if event.Type == entity.EventStatus && event.Status != nil && *event.Status == entity.LotFinished {
+		t.finished = true
+	}
+
+	for ch := range t.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(t.subs, ch)
+			close(ch)
+		}
+	}
+
+	b.evict(event.LotID, t)
+}
+
+// Subscribe - subscribing to the events of the lot. The kept events after lastID
+// are returned first (none if lastID is zero), then the new ones come through the
+// channel. The channel is closed when the subscriber is dropped or the broker is
+// closed; cancel has to be called once the subscriber is done.
+func (b *Broker) Subscribe(lotID, lastID int64) ([]*entity.LotEvent, <-chan *entity.LotEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan *entity.LotEvent, _subscriberBuffer)
+
+	if b.closed {
+		close(ch)
+		return nil, ch, func() {}
+	}
+
+	t := b.topic(lotID)
+
+	backlog := []*entity.LotEvent{}
+	if lastID > 0 {
+		for _, event := range t.history {
+			if event.ID > lastID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	t.subs[ch] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := t.subs[ch]; ok {
+			delete(t.subs, ch)
+			close(ch)
+		}
+
+		b.evict(lotID, t)
+	}
+
+	return backlog, ch, cancel
+}
+
+// Close - dropping all the subscribers, e.g. to let the streams end before the
+// HTTP server is shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for _, t := range b.lots {
+		for ch := range t.subs {
+			delete(t.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broker) topic(lotID int64) *topic {
+	t, ok := b.lots[lotID]
+	if !ok {
+		t = &topic{subs: make(map[chan *entity.LotEvent]struct{})}
+		b.lots[lotID] = t
+	}
+
+	return t
+}
+
+// evict drops the topic of the lot once nobody is subscribed to it, if nothing
+// more is going to happen to the lot (it is finished) or there is nothing kept
+// to resume from.
+func (b *Broker) evict(lotID int64, t *topic) {
+	if len(t.subs) > 0 || b.lots[lotID] != t {
+		return
+	}
+
+	if t.finished || len(t.history) == 0 {
+		delete(b.lots, lotID)
+	}
+}
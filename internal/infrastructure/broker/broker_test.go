@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+func finishedEvent(lotID int64) *entity.LotEvent {
+	status := entity.LotFinished
+	return &entity.LotEvent{LotID: lotID, Type: entity.EventStatus, Status: &status}
+}
+
+func TestBrokerEvictsFinishedLots(t *testing.T) {
+	b := New(10)
+
+	// Nobody is listening when the lot finishes.
+	b.Publish(&entity.LotEvent{LotID: 1, Type: entity.EventBid})
+	b.Publish(finishedEvent(1))
+
+	if _, ok := b.lots[1]; ok {
+		t.Error("topic of a finished lot without subscribers is kept")
+	}
+
+	// The last subscriber leaves after the lot has finished.
+	_, _, cancel := b.Subscribe(2, 0)
+	b.Publish(finishedEvent(2))
+
+	if _, ok := b.lots[2]; !ok {
+		t.Fatal("topic of a finished lot is dropped while it has a subscriber")
+	}
+
+	cancel()
+
+	if _, ok := b.lots[2]; ok {
+		t.Error("topic of a finished lot is kept after the last subscriber left")
+	}
+
+	// A subscriber of a lot nothing has happened to leaves nothing behind.
+	_, _, cancel = b.Subscribe(3, 0)
+	cancel()
+
+	if _, ok := b.lots[3]; ok {
+		t.Error("empty topic is kept after the last subscriber left")
+	}
+
+	// The events of an open lot are kept for the subscribers to resume from.
+	b.Publish(&entity.LotEvent{LotID: 4, Type: entity.EventBid})
+
+	if _, ok := b.lots[4]; !ok {
+		t.Error("topic of an open lot is dropped")
+	}
+}
+
+func TestBrokerSeqNeverGoesBack(t *testing.T) {
+	b := New(10)
+
+	b.Publish(&entity.LotEvent{ID: 7, LotID: 1, Type: entity.EventBid})
+	b.Publish(&entity.LotEvent{ID: 5, LotID: 1, Type: entity.EventBid})
+
+	event := &entity.LotEvent{LotID: 1, Type: entity.EventPrice}
+	b.Publish(event)
+
+	if event.ID != 8 {
+		t.Errorf("got id %d after a late event, want 8", event.ID)
+	}
+}
@@ -0,0 +1,232 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/postgres"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+// AccountRepo -.
+type AccountRepo struct {
+	*postgres.Postgres
+}
+
+// NewAccountRepo -.
+func NewAccountRepo(pg *postgres.Postgres) *AccountRepo {
+	return &AccountRepo{pg}
+}
+
+// GetByUser method for fetching the account of the user.
+func (r *AccountRepo) GetByUser(userID int64) (*entity.Account, error) {
+	query := `
+		SELECT id, user_id, balance, COALESCE((SELECT SUM(amount) FROM holds WHERE account_id = accounts.id), 0),
+			created_at, updated_at
+		FROM accounts
+		WHERE user_id = $1`
+
+	var account entity.Account
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.Pool.QueryRow(ctx, query, userID).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Balance,
+		&account.Held,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	account.Available = account.Balance - account.Held
+
+	return &account, nil
+}
+
+// GetEntries method for fetching the ledger entries of the account, the latest
+// first.
+func (r *AccountRepo) GetEntries(accountID string) ([]*entity.LedgerEntry, error) {
+	query := `
+		SELECT e.id, e.transaction_id, e.account_id, t.kind, t.reference, e.amount, e.created_at
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account_id = $1
+		ORDER BY e.id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []*entity.LedgerEntry{}
+
+	for rows.Next() {
+		var entry entity.LedgerEntry
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&entry.Kind,
+			&entry.Reference,
+			&entry.Amount,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Post method for writing a ledger transaction with its entries. The balances of
+// the accounts are kept by the database as the entries come in, it also makes
+// sure that the entries add up to zero. A transaction which would take more out
+// of a user account than is available (not held by bids) fails with
+// ErrInsufficientFunds.
+func (r *AccountRepo) Post(t *entity.LedgerTransaction) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	return postTransaction(ctx, tx, t)
+}
+
+// accountID returns the account number of the user.
+func accountID(ctx context.Context, tx pgx.Tx, userID int64) (string, error) {
+	query := "SELECT id FROM accounts WHERE user_id = $1"
+
+	var id string
+
+	err := tx.QueryRow(ctx, query, userID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return "", entity.ErrRecordNotFound
+		default:
+			return "", err
+		}
+	}
+
+	return id, nil
+}
+
+// postTransaction writes the ledger transaction inside tx.
+func postTransaction(ctx context.Context, tx pgx.Tx, t *entity.LedgerTransaction) error {
+	ids := make([]string, 0, len(t.Entries))
+	for _, entry := range t.Entries {
+		ids = append(ids, entry.AccountID)
+	}
+
+	// Lock the accounts in the same order every time, so that two transactions
+	// between the same accounts can't deadlock.
+	query := "SELECT id FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE"
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// The database only keeps a balance from going below zero, the funds held
+	// by bids are checked here.
+	query = `
+		SELECT balance - COALESCE((SELECT SUM(amount) FROM holds WHERE account_id = accounts.id), 0)
+		FROM accounts
+		WHERE id = $1 AND user_id IS NOT NULL`
+
+	for _, entry := range t.Entries {
+		if entry.Amount > 0 {
+			continue
+		}
+
+		var available int64
+
+		err = tx.QueryRow(ctx, query, entry.AccountID).Scan(&available)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return err
+		}
+
+		if available < -entry.Amount {
+			return entity.ErrInsufficientFunds
+		}
+	}
+
+	query = `
+		INSERT INTO ledger_transactions (kind, reference, created_by) VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, t.Kind, t.Reference, t.CreatedBy).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO ledger_entries (transaction_id, account_id, amount) VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	var e *pgconn.PgError
+
+	for _, entry := range t.Entries {
+		entry.TransactionID = t.ID
+		entry.Kind = t.Kind
+		entry.Reference = t.Reference
+
+		err = tx.QueryRow(ctx, query, t.ID, entry.AccountID, entry.Amount).Scan(&entry.ID, &entry.CreatedAt)
+		if err != nil {
+			switch {
+			case errors.As(err, &e) && e.Code == pgerrcode.CheckViolation && e.ConstraintName == "accounts_balance_check":
+				return entity.ErrInsufficientFunds
+			default:
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/postgres"
+	"github.com/jackc/pgx/v4"
+)
+
+const attachmentColumns = `id, lot_id, filename, content_type, size, storage_key, thumbnail_key, created_at`
+
+// AttachmentRepo -.
+type AttachmentRepo struct {
+	*postgres.Postgres
+}
+
+// NewAttachmentRepo -.
+func NewAttachmentRepo(pg *postgres.Postgres) *AttachmentRepo {
+	return &AttachmentRepo{pg}
+}
+
+// GetAll method for fetching the attachments of the given lots, in the order
+// they were uploaded.
+func (r *AttachmentRepo) GetAll(lotIDs ...int64) ([]*entity.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE lot_id = ANY($1)
+		ORDER BY lot_id, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, lotIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	attachments := []*entity.Attachment{}
+
+	for rows.Next() {
+		var attachment entity.Attachment
+
+		err := scanAttachment(rows, &attachment)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, &attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// Get method for fetching an attachment of the lot.
+func (r *AttachmentRepo) Get(lotID, id int64) (*entity.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE lot_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var attachment entity.Attachment
+
+	err := scanAttachment(r.Pool.QueryRow(ctx, query, lotID, id), &attachment)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &attachment, nil
+}
+
+// Insert method for inserting a new record in the attachments table.
+func (r *AttachmentRepo) Insert(attachment *entity.Attachment) error {
+	query := `
+		INSERT INTO attachments (lot_id, filename, content_type, size, storage_key, thumbnail_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	args := []interface{}{
+		attachment.LotID,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.Key,
+		attachment.ThumbnailKey,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return r.Pool.QueryRow(ctx, query, args...).Scan(&attachment.ID, &attachment.CreatedAt)
+}
+
+// Delete method for deleting an attachment of the lot.
+func (r *AttachmentRepo) Delete(lotID, id int64) error {
+	query := "DELETE FROM attachments WHERE lot_id = $1 AND id = $2"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.Pool.Exec(ctx, query, lotID, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return entity.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanAttachment(row pgx.Row, attachment *entity.Attachment) error {
+	return row.Scan(
+		&attachment.ID,
+		&attachment.LotID,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.Key,
+		&attachment.ThumbnailKey,
+		&attachment.CreatedAt,
+	)
+}
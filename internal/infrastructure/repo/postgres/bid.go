@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/ElOtro/auction-go/internal/entity"
@@ -19,10 +20,15 @@ func NewBidRepo(pg *postgres.Postgres) *BidRepo {
 	return &BidRepo{pg}
 }
 
-// GetAll method for fetching all records from the bids table for given lot.
-func (r *BidRepo) GetAll(lotID int64) ([]*entity.Bid, error) {
+// GetAll method for fetching all records from the bids table for given lot. If
+// bidderID is not nil only the bids of that bidder are returned.
+func (r *BidRepo) GetAll(lotID int64, bidderID *int64) ([]*entity.Bid, error) {
 	// Construct the SQL query to retrieve all records.
-	query := "SELECT id, amount, bidder_id, created_at, updated_at FROM bids WHERE lot_id = $1"
+	query := `
+		SELECT id, amount, price, bidder_id, auto, created_at, updated_at
+		FROM bids
+		WHERE lot_id = $1 AND ($2::bigint IS NULL OR bidder_id = $2)
+		ORDER BY id`
 
 	// Create a context with a 3-second timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -30,7 +36,7 @@ func (r *BidRepo) GetAll(lotID int64) ([]*entity.Bid, error) {
 
 	// Use QueryContext() to execute the query. This returns a sql.Rows resultset
 	// containing the result.
-	rows, err := r.Pool.Query(ctx, query, lotID)
+	rows, err := r.Pool.Query(ctx, query, lotID, bidderID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +55,9 @@ func (r *BidRepo) GetAll(lotID int64) ([]*entity.Bid, error) {
 		err := rows.Scan(
 			&bid.ID,
 			&bid.Amount,
+			&bid.Price,
 			&bid.BidderID,
+			&bid.Auto,
 			&bid.CreatedAt,
 			&bid.UpdatedAt,
 		)
@@ -70,44 +78,241 @@ func (r *BidRepo) GetAll(lotID int64) ([]*entity.Bid, error) {
 	return bids, nil
 }
 
-// Insert method for inserting a new record in the table.
-func (r *BidRepo) Insert(bid *entity.Bid) error {
+// Insert method for inserting a new record in the table. The lot row is locked
+// with SELECT ... FOR UPDATE for the whole transaction, so concurrent bids on the
+// same lot are serialized and never get the same price. The check function is
+// called with the locked lot and its current price before the bid is written and
+// is expected to fill in bid.Amount and bid.Price, it may also move lot.EndAt;
+// if it returns an error the transaction is rolled back and the error is
+// returned. Once the bid is written the proxy bids on the lot are given a chance
+// to answer it, the automatic counter-bids are returned. On a sealed-bid lot the
+// bid replaces the earlier bid of the bidder and nobody answers it.
+//
+// The bid holds the funds for its price on the bidder's account, the bid is
+// rejected with ErrInsufficientFunds if they are not available. The holds of the
+// bidders who have been outbid are released in the same transaction, which also
+// sends the events of the bids.
+func (r *BidRepo) Insert(bid *entity.Bid, check func(lot *entity.Lot, price int64) error) (counters []*entity.Bid, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-	tx, err := r.Pool.BeginTx(context.Background(), pgx.TxOptions{})
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer func() {
 		if err != nil {
 			tx.Rollback(context.Background())
 		} else {
-			tx.Commit(context.Background())
+			err = tx.Commit(ctx)
 		}
 	}()
 
-	// Get sum of bids for certain lot
-	query := "SELECT COALESCE(SUM(amount), 0) FROM bids WHERE lot_id = $1"
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	var sum int64
-	err = tx.QueryRow(ctx, query, bid.LotID).Scan(&sum)
-	defer cancel()
+	// Lock the lot, any other bid on it waits here until we commit.
+	lot, err := lockLot(ctx, tx, bid.LotID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the current price of the lot, the start price until the first bid. It is
+	// the price of the leading bid, not a sum of the amounts, so it works the same
+	// way for lots whose price goes down.
+	price := lot.StartPrice
+
+	top, err := topBid(ctx, tx, lot)
+	if err != nil {
+		return nil, err
+	}
+
+	if top != nil {
+		price = top.Price
+	}
+
+	endAt := lot.EndAt
+
+	err = check(lot, price)
+	if err != nil {
+		return nil, err
+	}
+
+	if lot.BidderPays() {
+		// A sealed bid replaces the bidder's earlier one, so does its hold.
+		err = holdFunds(ctx, tx, lot.ID, *bid.BidderID, bid.Price, !lot.Sealed())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if lot.Sealed() {
+		err = deleteBids(ctx, tx, lot.ID, *bid.BidderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = insertBid(ctx, tx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if lot.Sealed() {
+		bid.EndAt = &lot.EndAt
+		return nil, nil
+	}
+
+	counters, err = insertCounterBids(ctx, tx, lot, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if lot.BidderPays() {
+		leader := bid
+		if len(counters) > 0 {
+			leader = counters[len(counters)-1]
+		}
+
+		err = releaseHolds(ctx, tx, lot.ID, leader.BidderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The check may have moved the end of the auction (soft close).
+	extended := !lot.EndAt.Equal(endAt)
+	if extended {
+		err = extendLot(ctx, tx, lot)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bid.EndAt = &lot.EndAt
+
+	err = notifyEvents(ctx, tx, entity.NewBidEvents(lot, append([]*entity.Bid{bid}, counters...), extended))
+	if err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// lockLot selects the lot with FOR UPDATE, any other transaction which wants to
+// bid on it or close it waits until the current one is finished. A lot in the
+// trash is not found.
+func lockLot(ctx context.Context, tx pgx.Tx, id int64) (*entity.Lot, error) {
+	query := `SELECT ` + lotColumns + `
+		FROM lots
+		WHERE id = $1 AND destroyed_at IS NULL
+		FOR UPDATE`
+
+	var lot entity.Lot
+	err := scanLot(tx.QueryRow(ctx, query, id), &lot)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &lot, nil
+}
+
+// extendLot writes the new end time of the lot.
+func extendLot(ctx context.Context, tx pgx.Tx, lot *entity.Lot) error {
+	query := "UPDATE lots SET end_at = $1, version = version + 1, updated_at = NOW() WHERE id = $2 RETURNING version, updated_at"
+
+	return tx.QueryRow(ctx, query, lot.EndAt, lot.ID).Scan(&lot.Version, &lot.UpdatedAt)
+}
+
+// topBid returns the leading bid on the lot or nil if there are no bids yet.
+func topBid(ctx context.Context, tx pgx.Tx, lot *entity.Lot) (*entity.Bid, error) {
+	bids, err := topBids(ctx, tx, lot, 1)
+	if err != nil || len(bids) == 0 {
+		return nil, err
+	}
+
+	return bids[0], nil
+}
+
+// topBids returns up to limit bids on the lot in the order they rank, the
+// leading bid first and the earliest one first among equal prices. The highest
+// bid leads, on a reverse lot the lowest one.
+func topBids(ctx context.Context, tx pgx.Tx, lot *entity.Lot, limit int) ([]*entity.Bid, error) {
+	order := "price DESC"
+	if lot.Reverse() {
+		order = "price"
+	}
+
+	query := `
+		SELECT id, amount, price, lot_id, bidder_id, auto, created_at, updated_at
+		FROM bids
+		WHERE lot_id = $1
+		ORDER BY ` + order + `, id
+		LIMIT $2`
+
+	rows, err := tx.Query(ctx, query, lot.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	bids := []*entity.Bid{}
+
+	for rows.Next() {
+		var bid entity.Bid
 
+		err := rows.Scan(
+			&bid.ID,
+			&bid.Amount,
+			&bid.Price,
+			&bid.LotID,
+			&bid.BidderID,
+			&bid.Auto,
+			&bid.CreatedAt,
+			&bid.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		bids = append(bids, &bid)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+// deleteBids removes the bids of the bidder on the lot.
+func deleteBids(ctx context.Context, tx pgx.Tx, lotID, bidderID int64) error {
+	query := "DELETE FROM bids WHERE lot_id = $1 AND bidder_id = $2"
+
+	_, err := tx.Exec(ctx, query, lotID, bidderID)
+
+	return err
+}
+
+func insertBid(ctx context.Context, tx pgx.Tx, bid *entity.Bid) error {
 	// Define the SQL query for inserting a new record
-	sum += bid.Amount
-	query = `
-		INSERT INTO bids (amount, price, lot_id, bidder_id) VALUES ($1, $2, $3, $4)
+	query := `
+		INSERT INTO bids (amount, price, lot_id, bidder_id, auto) VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, bidder_id, price, created_at, updated_at`
 
 	args := []interface{}{
 		&bid.Amount,
-		sum,
+		&bid.Price,
 		&bid.LotID,
 		&bid.BidderID,
+		&bid.Auto,
 	}
 
-	// Use the QueryRow() method to execute the SQL query on our connection pool
-	return tx.QueryRow(context.Background(), query, args...).Scan(
+	// Use the QueryRow() method to execute the SQL query inside the transaction
+	return tx.QueryRow(ctx, query, args...).Scan(
 		&bid.ID,
 		&bid.BidderID,
 		&bid.Price,
@@ -115,3 +320,23 @@ func (r *BidRepo) Insert(bid *entity.Bid) error {
 		&bid.UpdatedAt,
 	)
 }
+
+// insertCounterBids answers the leading bid with the proxy bids set on the lot
+// and records the resulting counter-bids.
+func insertCounterBids(ctx context.Context, tx pgx.Tx, lot *entity.Lot, top *entity.Bid) ([]*entity.Bid, error) {
+	proxies, err := getProxyBids(ctx, tx, lot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := entity.CounterBids(lot, top, proxies)
+
+	for _, bid := range counters {
+		err = insertBid(ctx, tx, bid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return counters, nil
+}
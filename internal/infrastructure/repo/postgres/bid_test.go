@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/postgres"
+)
+
+// testPostgres connects to the database named by TEST_PG_URL, the migrations
+// have to be applied to it. The test is skipped without one.
+func testPostgres(t *testing.T, poolSize int) *postgres.Postgres {
+	t.Helper()
+
+	url := os.Getenv("TEST_PG_URL")
+	if url == "" {
+		t.Skip("TEST_PG_URL is not set")
+	}
+
+	pg, err := postgres.New(url, postgres.MaxPoolSize(poolSize), postgres.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(pg.Close)
+
+	return pg
+}
+
+// createTestUser adds a user with an account holding the balance. The balance is
+// written straight to the account, the test doesn't go through the ledger.
+func createTestUser(t *testing.T, pg *postgres.Postgres, balance int64) int64 {
+	t.Helper()
+
+	ctx := context.Background()
+
+	var id int64
+
+	email := fmt.Sprintf("bidder-%d@example.com", time.Now().UnixNano())
+
+	err := pg.Pool.QueryRow(ctx, `INSERT INTO users (name, email, password_hash) VALUES ('Bidder', $1, '\x00')
+		RETURNING id`, email).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		pg.Pool.Exec(context.Background(), "DELETE FROM users WHERE id = $1", id)
+	})
+
+	_, err = pg.Pool.Exec(ctx, "INSERT INTO accounts (user_id, balance) VALUES ($1, $2)", id, balance)
+	if err != nil {
+		t.Fatalf("insert account: %v", err)
+	}
+
+	return id
+}
+
+func TestBidRepoInsertConcurrent(t *testing.T) {
+	const bidders = 20
+
+	pg := testPostgres(t, bidders)
+	ctx := context.Background()
+
+	creatorID := createTestUser(t, pg, 0)
+
+	ids := make([]int64, bidders)
+	for i := range ids {
+		ids[i] = createTestUser(t, pg, 1_000_000)
+	}
+
+	// The lot is cleaned up first, the holds on the accounts go with it.
+	var lotID int64
+
+	err := pg.Pool.QueryRow(ctx, `
+		INSERT INTO lots (status, title, start_price, step_price, creator_id, start_at, end_at)
+		VALUES ($1, 'Concurrent bids', 100, 10, $2, NOW() - INTERVAL '1 hour', NOW() + INTERVAL '1 hour')
+		RETURNING id`, entity.LotPublished, creatorID).Scan(&lotID)
+	if err != nil {
+		t.Fatalf("insert lot: %v", err)
+	}
+
+	t.Cleanup(func() {
+		pg.Pool.Exec(context.Background(), "DELETE FROM lots WHERE id = $1", lotID)
+	})
+
+	r := NewBidRepo(pg)
+
+	// Every bid raises the price it sees by one step, all of them at once.
+	var wg sync.WaitGroup
+
+	errs := make(chan error, bidders)
+
+	for _, id := range ids {
+		id := id
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			bid := &entity.Bid{LotID: lotID, BidderID: &id}
+
+			_, err := r.Insert(bid, func(lot *entity.Lot, price int64) error {
+				bid.Amount = lot.StepPrice
+				bid.Price = price + lot.StepPrice
+				return nil
+			})
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	bids, err := r.GetAll(lotID, nil)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+
+	if len(bids) != bidders {
+		t.Fatalf("got %d bids, want %d", len(bids), bidders)
+	}
+
+	// In the order they were written the prices go up one step at a time, none
+	// of the bids saw the same price.
+	for i, bid := range bids {
+		want := int64(100 + 10*(i+1))
+		if bid.Price != want {
+			t.Errorf("bid %d: price %d, want %d", i, bid.Price, want)
+		}
+	}
+
+	// Only the leader still has funds held on the lot.
+	leader := bids[len(bids)-1]
+
+	rows, err := pg.Pool.Query(ctx, `
+		SELECT a.user_id, h.amount FROM holds h JOIN accounts a ON a.id = h.account_id
+		WHERE h.lot_id = $1`, lotID)
+	if err != nil {
+		t.Fatalf("select holds: %v", err)
+	}
+	defer rows.Close()
+
+	var holds int
+
+	for rows.Next() {
+		var userID, amount int64
+
+		err := rows.Scan(&userID, &amount)
+		if err != nil {
+			t.Fatalf("scan hold: %v", err)
+		}
+
+		holds++
+
+		if userID != *leader.BidderID || amount != leader.Price {
+			t.Errorf("hold of user %d for %d, want only user %d for %d", userID, amount, *leader.BidderID, leader.Price)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("holds: %v", err)
+	}
+
+	if holds != 1 {
+		t.Errorf("got %d holds, want 1", holds)
+	}
+}
@@ -0,0 +1,222 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/postgres"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+// categoryColumns is the list of columns selected for a category c, scanCategory
+// reads them back. The active lots are the published ones in the category and
+// all its subcategories.
+var categoryColumns = fmt.Sprintf(`c.id, c.parent_id, c.name, c.slug,
+	(SELECT count(*) FROM lots WHERE lots.status = %d AND lots.destroyed_at IS NULL
+		AND lots.category_id IN (%s)),
+	c.created_at, c.updated_at`, entity.LotPublished, categorySubtree("c.id"))
+
+// categorySubtree selects the ids of the given category and of all its
+// subcategories.
+func categorySubtree(id string) string {
+	return `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + id + `
+			UNION ALL
+			SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+		)
+		SELECT id FROM subtree`
+}
+
+// CategoryRepo -.
+type CategoryRepo struct {
+	*postgres.Postgres
+}
+
+// NewCategoryRepo -.
+func NewCategoryRepo(pg *postgres.Postgres) *CategoryRepo {
+	return &CategoryRepo{pg}
+}
+
+// GetAll method for fetching all records from the categories table, ordered by
+// name.
+func (r *CategoryRepo) GetAll() ([]*entity.Category, error) {
+	query := `SELECT ` + categoryColumns + `
+		FROM categories c
+		ORDER BY c.name, c.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	categories := []*entity.Category{}
+
+	for rows.Next() {
+		var category entity.Category
+
+		err := scanCategory(rows, &category)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// Get method for fetching a specific record from the categories table.
+func (r *CategoryRepo) Get(id int64) (*entity.Category, error) {
+	if id < 1 {
+		return nil, entity.ErrRecordNotFound
+	}
+
+	query := `SELECT ` + categoryColumns + `
+		FROM categories c
+		WHERE c.id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var category entity.Category
+
+	err := scanCategory(r.Pool.QueryRow(ctx, query, id), &category)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &category, nil
+}
+
+// InSubtree method for checking whether the category id is the root category or
+// one of its subcategories.
+func (r *CategoryRepo) InSubtree(root, id int64) (bool, error) {
+	query := `SELECT $2 IN (` + categorySubtree("$1") + `)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var found bool
+
+	err := r.Pool.QueryRow(ctx, query, root, id).Scan(&found)
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// Insert method for inserting a new record in the categories table.
+func (r *CategoryRepo) Insert(category *entity.Category) error {
+	query := `
+		INSERT INTO categories (parent_id, name, slug) VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	args := []interface{}{category.ParentID, category.Name, category.Slug}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.Pool.QueryRow(ctx, query, args...).Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+
+	return categoryWriteError(err)
+}
+
+// Update method for updating a specific record in the categories table.
+func (r *CategoryRepo) Update(category *entity.Category) error {
+	query := `
+		UPDATE categories
+		SET parent_id = $1, name = $2, slug = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+
+	args := []interface{}{category.ParentID, category.Name, category.Slug, category.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.Pool.QueryRow(ctx, query, args...).Scan(&category.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrRecordNotFound
+	}
+
+	return categoryWriteError(err)
+}
+
+// Delete method for deleting a specific record from the categories table. A
+// category with subcategories can't be deleted, its lots are left without a
+// category.
+func (r *CategoryRepo) Delete(id int64) error {
+	if id < 1 {
+		return entity.ErrRecordNotFound
+	}
+
+	query := "DELETE FROM categories WHERE id = $1"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.Pool.Exec(ctx, query, id)
+	if err != nil {
+		var e *pgconn.PgError
+
+		switch {
+		case errors.As(err, &e) && e.Code == pgerrcode.ForeignKeyViolation:
+			return entity.ErrCategoryNotEmpty
+		default:
+			return err
+		}
+	}
+
+	if result.RowsAffected() == 0 {
+		return entity.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanCategory(row pgx.Row, category *entity.Category) error {
+	return row.Scan(
+		&category.ID,
+		&category.ParentID,
+		&category.Name,
+		&category.Slug,
+		&category.ActiveLots,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+}
+
+// categoryWriteError turns the constraint violations of a written category into
+// ErrDuplicateSlug and ErrUnknownCategory (the parent doesn't exist).
+func categoryWriteError(err error) error {
+	var e *pgconn.PgError
+
+	switch {
+	case errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation:
+		return entity.ErrDuplicateSlug
+	case errors.As(err, &e) && e.Code == pgerrcode.ForeignKeyViolation:
+		return entity.ErrUnknownCategory
+	default:
+		return err
+	}
+}
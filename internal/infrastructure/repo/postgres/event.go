@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/logger"
+	"github.com/ElOtro/auction-go/pkg/postgres"
+	"github.com/jackc/pgx/v4"
+)
+
+// EventChannel is the notification channel the lot events are sent on.
+const EventChannel = "lot_events"
+
+// notifyQuery sends an event on the channel, numbered with the next id from the
+// sequence.
+const notifyQuery = `SELECT pg_notify($1, jsonb_set($2::jsonb, '{id}', to_jsonb(nextval('lot_event_id_seq')))::text)`
+
+// EventRepo - publishes the lot events with NOTIFY, so that every instance
+// listening on EventChannel can pass them on to its own subscribers. The events
+// of the bids are sent within the transactions writing them, see notifyEvents;
+// the others are published once the change they report has been committed.
+type EventRepo struct {
+	*postgres.Postgres
+	l logger.Interface
+}
+
+// NewEventRepo -.
+func NewEventRepo(pg *postgres.Postgres, l logger.Interface) *EventRepo {
+	return &EventRepo{pg, l}
+}
+
+// Publish method for sending the event to all instances. The id of the event is
+// taken from a sequence, so it is the same everywhere and a client can resume
+// on any instance. A failure is only logged, the change itself is already done.
+func (r *EventRepo) Publish(event *entity.LotEvent) {
+	js, err := json.Marshal(event)
+	if err != nil {
+		r.l.Error(fmt.Errorf("repo - EventRepo - Publish - json.Marshal: %w", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = r.Pool.Exec(ctx, notifyQuery, EventChannel, string(js))
+	if err != nil {
+		r.l.Error(fmt.Errorf("repo - EventRepo - Publish - pg_notify: %w", err))
+	}
+}
+
+// notifyEvents sends the events within the transaction. The notifications are
+// delivered when it commits, in the order the transactions commit in; as the
+// lot is locked by then, the ids of the events of a lot grow in the same order.
+func notifyEvents(ctx context.Context, tx pgx.Tx, events []*entity.LotEvent) error {
+	for _, event := range events {
+		js, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, notifyQuery, EventChannel, string(js))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeEvent reads a lot event back from the payload of a notification.
+func DecodeEvent(payload string) (*entity.LotEvent, error) {
+	var event entity.LotEvent
+
+	err := json.Unmarshal([]byte(payload), &event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetHistory method for fetching the history of the lot, the earliest step
+// first.
+func (r LotRepo) GetHistory(lotID int64) ([]*entity.LotHistory, error) {
+	query := `
+		SELECT id, lot_id, kind, user_id, price, created_at
+		FROM lot_history
+		WHERE lot_id = $1
+		ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := []*entity.LotHistory{}
+
+	for rows.Next() {
+		var step entity.LotHistory
+
+		err := rows.Scan(
+			&step.ID,
+			&step.LotID,
+			&step.Kind,
+			&step.UserID,
+			&step.Price,
+			&step.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, &step)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// addHistory records a step of the sale of the lot inside tx.
+func addHistory(ctx context.Context, tx pgx.Tx, lotID int64, kind entity.HistoryKind, userID, price *int64) error {
+	query := "INSERT INTO lot_history (lot_id, kind, user_id, price) VALUES ($1, $2, $3, $4)"
+
+	_, err := tx.Exec(ctx, query, lotID, kind, userID, price)
+
+	return err
+}
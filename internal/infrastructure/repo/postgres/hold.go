@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/jackc/pgx/v4"
+)
+
+// holdFunds sets the amount held on the user's account for the lot. With raise
+// the hold only goes up, a bigger hold (e.g. for a proxy bid) stays as it is.
+// The account row is locked, so holds on different lots can't overdraw it
+// together; if the balance not held for other lots doesn't cover the amount the
+// hold fails with ErrInsufficientFunds.
+func holdFunds(ctx context.Context, tx pgx.Tx, lotID, userID, amount int64, raise bool) error {
+	query := `
+		SELECT a.id, a.balance - COALESCE((SELECT SUM(amount) FROM holds WHERE account_id = a.id AND lot_id <> $2), 0),
+			COALESCE((SELECT amount FROM holds WHERE account_id = a.id AND lot_id = $2), 0)
+		FROM accounts a
+		WHERE a.user_id = $1
+		FOR UPDATE`
+
+	var accountID string
+	var available, held int64
+
+	err := tx.QueryRow(ctx, query, userID, lotID).Scan(&accountID, &available, &held)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return entity.ErrInsufficientFunds
+		default:
+			return err
+		}
+	}
+
+	if raise && held > amount {
+		amount = held
+	}
+
+	if available < amount {
+		return entity.ErrInsufficientFunds
+	}
+
+	query = `
+		INSERT INTO holds (account_id, lot_id, amount) VALUES ($1, $2, $3)
+		ON CONFLICT (lot_id, account_id) DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()`
+
+	_, err = tx.Exec(ctx, query, accountID, lotID, amount)
+
+	return err
+}
+
+// releaseHolds releases the holds on the lot of everybody but the given user
+// (nil releases all of them).
+func releaseHolds(ctx context.Context, tx pgx.Tx, lotID int64, keep *int64) error {
+	query := `
+		DELETE FROM holds
+		WHERE lot_id = $1 AND account_id NOT IN (SELECT id FROM accounts WHERE user_id = $2)`
+
+	_, err := tx.Exec(ctx, query, lotID, keep)
+
+	return err
+}
+
+// releaseHold releases the hold of the user on the lot.
+func releaseHold(ctx context.Context, tx pgx.Tx, lotID, userID int64) error {
+	query := `
+		DELETE FROM holds
+		WHERE lot_id = $1 AND account_id IN (SELECT id FROM accounts WHERE user_id = $2)`
+
+	_, err := tx.Exec(ctx, query, lotID, userID)
+
+	return err
+}
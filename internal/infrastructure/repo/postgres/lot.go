@@ -3,13 +3,32 @@ package repo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ElOtro/auction-go/internal/entity"
 	"github.com/ElOtro/auction-go/pkg/postgres"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
 )
 
+// lotColumns is the list of columns selected for a lot, scanLot reads them back.
+// The reserve price is never compared outside the database, reserve_met tells
+// whether the leading bid has reached it.
+const lotColumns = `id, status, type, title, description, start_price, end_price, step_price, reserve_price,
+	` + reserveMetColumn + `,
+	buy_now_price, creator_id, winner_id, start_at, end_at, soft_close_window, soft_close_extension, floor_price,
+	drop_interval, settlement_status, category_id, tags, notify, destroyed_at, version, created_at, updated_at`
+
+// reserveMetColumn tells whether the leading bid has reached the reserve price:
+// the highest bid has to be at or above it, the lowest bid of a reverse lot at or
+// below it.
+const reserveMetColumn = `(reserve_price IS NULL OR COALESCE(CASE WHEN type = 'reverse'
+	THEN reserve_price >= (SELECT MIN(price) FROM bids WHERE bids.lot_id = lots.id)
+	ELSE reserve_price <= (SELECT MAX(price) FROM bids WHERE bids.lot_id = lots.id) END, false))`
+
 // LotRepo -.
 type LotRepo struct {
 	*postgres.Postgres
@@ -20,22 +39,69 @@ func NewLotRepo(pg *postgres.Postgres) *LotRepo {
 	return &LotRepo{pg}
 }
 
-// GetAll method for fetching all records from the lots table.
-func (r LotRepo) GetAll() ([]*entity.Lot, error) {
-	// Construct the SQL query to retrieve all records.
-	query := `SELECT id, status, title, description, start_price, end_price, step_price, creator_id, winner_id, 
-	          start_at, end_at, notify, created_at, updated_at
-		      FROM lots`
+// GetAll method for fetching the page of the lots matching the filters, together
+// with the pagination metadata of the whole list.
+func (r LotRepo) GetAll(filters entity.LotFilters) ([]*entity.Lot, entity.Metadata, error) {
+	// A filter which is NULL matches every lot. The title is matched literally,
+	// the wildcards in it are escaped.
+	where := `
+		WHERE destroyed_at IS NULL
+		AND (title ILIKE '%' || $1 || '%' OR $1 = '')
+		AND ($2::integer IS NULL OR status = $2)
+		AND ($3::bigint IS NULL OR creator_id = $3)
+		AND ($4::bigint IS NULL OR start_price >= $4)
+		AND ($5::bigint IS NULL OR start_price <= $5)
+		AND ($6::timestamptz IS NULL OR start_at >= $6)
+		AND ($7::timestamptz IS NULL OR start_at <= $7)
+		AND ($8::timestamptz IS NULL OR end_at >= $8)
+		AND ($9::timestamptz IS NULL OR end_at <= $9)
+		AND ($10::bigint IS NULL OR category_id IN (` + categorySubtree("$10") + `))
+		AND ($11 = '' OR tags @> ARRAY[$11::text])`
+
+	// Construct the SQL query to retrieve the records. The sort column and direction
+	// come from the safelist of the filters, the ties are broken by the id so that
+	// the pages don't overlap.
+	query := fmt.Sprintf(`
+		SELECT `+lotColumns+`
+		FROM lots
+		%s
+		ORDER BY %s %s, id ASC
+		LIMIT $12 OFFSET $13`, where, filters.SortColumn(), filters.SortDirection())
+
+	args := []interface{}{
+		escapeLike(filters.Title),
+		filters.Status,
+		filters.CreatorID,
+		filters.MinPrice,
+		filters.MaxPrice,
+		filters.StartFrom,
+		filters.StartTo,
+		filters.EndFrom,
+		filters.EndTo,
+		filters.CategoryID,
+		filters.Tag,
+		filters.Limit(),
+		filters.Offset(),
+	}
 
 	// Create a context with a 3-second timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	// The matching lots are counted on their own, a page past the last one has
+	// no rows to carry the total.
+	totalRecords := 0
+
+	err := r.Pool.QueryRow(ctx, `SELECT count(*) FROM lots`+where, args[:11]...).Scan(&totalRecords)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
 	// Use QueryContext() to execute the query. This returns a sql.Rows resultset
 	// containing the result.
-	rows, err := r.Pool.Query(ctx, query)
+	rows, err := r.Pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, entity.Metadata{}, err
 	}
 
 	defer rows.Close()
@@ -47,37 +113,101 @@ func (r LotRepo) GetAll() ([]*entity.Lot, error) {
 		// Initialize an empty struct to hold the data for an individual.
 		var lot entity.Lot
 
+		err := scanLot(rows, &lot)
+		if err != nil {
+			return nil, entity.Metadata{}, err
+		}
+
+		// Add the Lot struct to the slice.
+		lots = append(lots, &lot)
+	}
+
+	// When the rows.Next() loop has finished, call rows.Err() to retrieve any error
+	// that was encountered during the iteration.
+	if err = rows.Err(); err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	metadata := entity.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return lots, metadata, nil
+}
+
+// Search method for fetching the page of the lots matching the full-text query,
+// ranked by how well they match. Only the page is highlighted, ts_headline works
+// on the text of the lot rather than on the index. The text is HTML-escaped
+// before it is highlighted, the <mark> tags are the only markup in the result.
+// The query is read in the search language of each lot, the one its search
+// column is built in.
+func (r LotRepo) Search(q entity.LotSearchQuery) ([]*entity.LotSearch, entity.Metadata, error) {
+	tsquery := "websearch_to_tsquery(lots.search_language, $1)"
+	text := q.Query
+	if q.Prefix {
+		tsquery = "to_tsquery(lots.search_language, $1)"
+		text = q.PrefixQuery()
+	}
+
+	query := `
+		SELECT id, status,
+			ts_headline(search_language, title, query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+			ts_headline(search_language, description, query, 'MaxFragments=2, MaxWords=20, MinWords=5, StartSel=<mark>, StopSel=</mark>'),
+			rank
+		FROM (
+			SELECT id, status, search_language, ` + htmlEscape("COALESCE(title, '')") + ` AS title,
+				` + htmlEscape("COALESCE(description, '')") + ` AS description, query,
+				ts_rank_cd(search, query) AS rank
+			FROM lots, ` + tsquery + ` query
+			WHERE search @@ query AND destroyed_at IS NULL
+			ORDER BY rank DESC, id
+			LIMIT $2 OFFSET $3
+		) found
+		ORDER BY rank DESC, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	totalRecords := 0
+
+	countQuery := `SELECT count(*) FROM lots, ` + tsquery + ` query WHERE search @@ query AND destroyed_at IS NULL`
+
+	err := r.Pool.QueryRow(ctx, countQuery, text).Scan(&totalRecords)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	rows, err := r.Pool.Query(ctx, query, text, q.Limit(), q.Offset())
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	lots := []*entity.LotSearch{}
+
+	for rows.Next() {
+		var lot entity.LotSearch
+
 		err := rows.Scan(
 			&lot.ID,
 			&lot.Status,
 			&lot.Title,
-			&lot.Description,
-			&lot.StartPrice,
-			&lot.EndPrice,
-			&lot.StepPrice,
-			&lot.CreatorID,
-			&lot.WinnerID,
-			&lot.StartAt,
-			&lot.EndAt,
-			&lot.Notify,
-			&lot.CreatedAt,
-			&lot.UpdatedAt,
+			&lot.Snippet,
+			&lot.Rank,
 		)
 		if err != nil {
-			return nil, err
+			return nil, entity.Metadata{}, err
 		}
 
-		// Add the Lot struct to the slice.
 		lots = append(lots, &lot)
 	}
 
-	// When the rows.Next() loop has finished, call rows.Err() to retrieve any error
-	// that was encountered during the iteration.
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, entity.Metadata{}, err
 	}
 
-	return lots, nil
+	metadata := entity.CalculateMetadata(totalRecords, q.Page, q.PageSize)
+
+	return lots, metadata, nil
 }
 
 // Get method for fetching a specific record from the lots table.
@@ -87,10 +217,9 @@ func (r LotRepo) Get(id int64) (*entity.Lot, error) {
 	}
 
 	// Define the SQL query for retrieving data.
-	query := `SELECT id, status, title, description, start_price, end_price, step_price, creator_id, winner_id, 
-	          start_at, end_at, notify, created_at, updated_at
+	query := `SELECT ` + lotColumns + `
 		      FROM lots
-			  WHERE id = $1`
+			  WHERE id = $1 AND destroyed_at IS NULL`
 
 	var lot entity.Lot
 
@@ -99,22 +228,7 @@ func (r LotRepo) Get(id int64) (*entity.Lot, error) {
 	defer cancel()
 
 	// Execute the query using the QueryRow() method, passing in the provided id value
-	err := r.Pool.QueryRow(ctx, query, id).Scan(
-		&lot.ID,
-		&lot.Status,
-		&lot.Title,
-		&lot.Description,
-		&lot.StartPrice,
-		&lot.EndPrice,
-		&lot.StepPrice,
-		&lot.CreatorID,
-		&lot.WinnerID,
-		&lot.StartAt,
-		&lot.EndAt,
-		&lot.Notify,
-		&lot.CreatedAt,
-		&lot.UpdatedAt,
-	)
+	err := scanLot(r.Pool.QueryRow(ctx, query, id), &lot)
 
 	// Handle any errors. If there was no matching found, Scan() will return
 	// a pgx.ErrNoRows error. We check for this and return our custom ErrRecordNotFound
@@ -133,98 +247,589 @@ func (r LotRepo) Get(id int64) (*entity.Lot, error) {
 
 // Insert method for inserting a new record in the table.
 func (r LotRepo) Insert(lot *entity.Lot) error {
+	window, extension := softCloseArgs(lot)
+	floor, interval := dutchArgs(lot)
+
 	// Define the SQL query for inserting a new record
 	query := `
-		INSERT INTO lots (status, title, description, start_price, end_price, step_price, creator_id, start_at, end_at, notify) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, creator_id, created_at, updated_at`
+		INSERT INTO lots (status, type, title, description, start_price, end_price, step_price, reserve_price, buy_now_price, 
+		creator_id, start_at, end_at, soft_close_window, soft_close_extension, floor_price, drop_interval, notify, category_id, tags,
+		search_language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20::regconfig)
+		RETURNING id, creator_id, reserve_price IS NULL, version, created_at, updated_at`
 
 	args := []interface{}{
 		&lot.Status,
+		&lot.Type,
 		&lot.Title,
 		&lot.Description,
 		&lot.StartPrice,
 		&lot.EndPrice,
 		&lot.StepPrice,
+		&lot.ReservePrice,
+		&lot.BuyNowPrice,
 		&lot.CreatorID,
 		&lot.StartAt,
 		&lot.EndAt,
+		window,
+		extension,
+		floor,
+		interval,
 		&lot.Notify,
+		&lot.CategoryID,
+		&lot.Tags,
+		&lot.SearchLanguage,
 	}
 
 	// Use the QueryRow() method to execute the SQL query on our connection pool
-	return r.Pool.QueryRow(context.Background(), query, args...).Scan(
+	err := r.Pool.QueryRow(context.Background(), query, args...).Scan(
 		&lot.ID,
 		&lot.CreatorID,
+		&lot.ReserveMet,
+		&lot.Version,
 		&lot.CreatedAt,
 		&lot.UpdatedAt,
 	)
+
+	return lotWriteError(err)
 }
 
 // Update method for updating a specific record.
 func (r LotRepo) Update(lot *entity.Lot) error {
+	window, extension := softCloseArgs(lot)
+	floor, interval := dutchArgs(lot)
+
 	query := `
 		UPDATE lots
-		SET status = $1, title = $2, description = $3, start_price = $4, end_price = $5, step_price = $6, 
-		winner_id = $7, start_at = $8, end_at = $9, notify = $10, destroyed_at = $11, updated_at = NOW() 
-		WHERE id = $12
-		RETURNING updated_at`
+		SET status = $1, type = $2, title = $3, description = $4, start_price = $5, end_price = $6, step_price = $7, 
+		reserve_price = $8, buy_now_price = $9, winner_id = $10, start_at = $11, end_at = $12, soft_close_window = $13, 
+		soft_close_extension = $14, floor_price = $15, drop_interval = $16, notify = $17,
+		category_id = $18, tags = $19, version = version + 1, updated_at = NOW()
+		WHERE id = $20 AND version = $21 AND destroyed_at IS NULL
+		RETURNING version, updated_at,
+		` + reserveMetColumn
 
 	// Create an args slice containing the values for the placeholder parameters.
 	args := []interface{}{
 		&lot.Status,
+		&lot.Type,
 		&lot.Title,
 		&lot.Description,
 		&lot.StartPrice,
 		&lot.EndPrice,
 		&lot.StepPrice,
+		&lot.ReservePrice,
+		&lot.BuyNowPrice,
 		&lot.WinnerID,
 		&lot.StartAt,
 		&lot.EndAt,
+		window,
+		extension,
+		floor,
+		interval,
 		&lot.Notify,
-		&lot.DestroyedAt,
+		&lot.CategoryID,
+		&lot.Tags,
 		&lot.ID,
+		&lot.Version,
 	}
 
 	// Use the QueryRow() method to execute the query, passing in the args slice as a
-	// variadic parameter and scanning the new version value into the movie struct.
-	return r.Pool.QueryRow(context.Background(), query, args...).Scan(
+	// variadic parameter and scanning the new version value into the lot struct.
+	err := r.Pool.QueryRow(context.Background(), query, args...).Scan(
+		&lot.Version,
 		&lot.UpdatedAt,
+		&lot.ReserveMet,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The lot has been changed or moved to the trash since it was read.
+		return entity.ErrEditConflict
+	}
+
+	return lotWriteError(err)
+}
+
+// lotWriteError turns the violation of the category foreign key of a written lot
+// into ErrUnknownCategory.
+func lotWriteError(err error) error {
+	var e *pgconn.PgError
+
+	if errors.As(err, &e) && e.Code == pgerrcode.ForeignKeyViolation && e.ConstraintName == "lots_category_id_fkey" {
+		return entity.ErrUnknownCategory
+	}
+
+	return err
 }
 
-// Delete method for deleting a specific record.
-func (r LotRepo) Delete(id int64) error {
+// Delete method for moving a lot to the trash. The lot row is locked and check
+// is called with it and its leading bid (nil if there are none), it is expected
+// to refuse a lot which still has funds held on it, nothing releases them while
+// the lot is in the trash. The lot is only marked with destroyed_at, it keeps
+// its bids and history until it is purged.
+func (r LotRepo) Delete(id int64, check func(lot *entity.Lot, top *entity.Bid) error) (err error) {
 	if id < 1 {
 		return entity.ErrRecordNotFound
 	}
 
-	// Construct the SQL query to delete the record.
+	// Create a context with a 3-second timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	// A lot which is already in the trash is not found.
+	lot, err := lockLot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	top, err := topBid(ctx, tx, lot)
+	if err != nil {
+		return err
+	}
+
+	err = check(lot, top)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		DELETE FROM lots WHERE id = $1`
+		UPDATE lots SET destroyed_at = NOW(), version = version + 1, updated_at = NOW() WHERE id = $1`
 
-	// Create a context with a 3-second timeout.
+	_, err = tx.Exec(ctx, query, id)
+
+	return err
+}
+
+// Publish method for opening pending lots whose start time has passed. It returns
+// the ids of the lots that were published.
+func (r LotRepo) Publish(now time.Time) ([]int64, error) {
+	query := `
+		UPDATE lots
+		SET status = $1, version = version + 1, updated_at = NOW()
+		WHERE status = $2 AND start_at <= $3 AND destroyed_at IS NULL
+		RETURNING id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, entity.LotPublished, entity.LotPending, now)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanIDs(rows)
+}
+
+// GetExpired method for fetching the ids of published lots whose end time has passed.
+func (r LotRepo) GetExpired(now time.Time) ([]int64, error) {
+	query := `
+		SELECT id FROM lots
+		WHERE status = $1 AND end_at <= $2 AND destroyed_at IS NULL
+		ORDER BY end_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, entity.LotPublished, now)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanIDs(rows)
+}
+
+// Finish method for closing a lot. The lot row is locked and check is called
+// with it, an error from check (e.g. the lot has already been closed by another
+// instance or the end time has moved) rolls the transaction back. Then the
+// highest bid is picked as the winner (unless it is under the reserve price) and
+// winner_id/end_price are written in the same transaction. The runner-up bid is
+// fetched too, it sets the price of a Vickrey lot. The holds of everybody but
+// the winner are released.
+func (r LotRepo) Finish(lot *entity.Lot, check func(lot *entity.Lot) error) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	// Execute the SQL query using the Exec() method, passing in the id variable as
-	// the value for the placeholder parameter. The Exec() method returns a sql.Result
-	// object.
-	result, err := r.Pool.Exec(ctx, query, id)
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 
-	// Call the RowsAffected() method on the sql.Result object to get the number of rows
-	// affected by the query.
-	rowsAffected := result.RowsAffected()
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
 
-	// If no rows were affected, we know that the products table didn't contain a record
-	// with the provided ID at the moment we tried to delete it. In that case we
-	// return an ErrRecordNotFound error.
-	if rowsAffected == 0 {
-		return entity.ErrRecordNotFound
+	// Lock the lot so that no bid can be placed while it is being closed.
+	locked, err := lockLot(ctx, tx, lot.ID)
+	if err != nil {
+		return err
+	}
+	*lot = *locked
+
+	err = check(lot)
+	if err != nil {
+		return err
+	}
+
+	bids, err := topBids(ctx, tx, lot, 2)
+	if err != nil {
+		return err
+	}
+
+	var top, runnerUp *entity.Bid
+	if len(bids) > 0 {
+		top = bids[0]
+	}
+	if len(bids) > 1 {
+		runnerUp = bids[1]
+	}
+
+	lot.Finish(top, runnerUp)
+
+	if lot.BidderPays() {
+		err = releaseHolds(ctx, tx, lot.ID, lot.WinnerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return finishLot(ctx, tx, lot)
+}
+
+// Buy method for buying a lot outright at a fixed price. The lot row is locked,
+// check is called with the lot and its leading bid (nil if there are none) and is
+// expected to set bid.Price, then the purchase is recorded as the final bid and
+// the lot is closed with the buyer as the winner, all in one transaction. The
+// funds for the price are held on the buyer's account (ErrInsufficientFunds if
+// they are not available) and the holds of the other bidders are released. The
+// events of the sale are sent in the same transaction.
+func (r LotRepo) Buy(lot *entity.Lot, bid *entity.Bid, check func(lot *entity.Lot, top *entity.Bid) error) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	locked, err := lockLot(ctx, tx, lot.ID)
+	if err != nil {
+		return err
+	}
+	*lot = *locked
+
+	top, err := topBid(ctx, tx, lot)
+	if err != nil {
+		return err
+	}
+
+	err = check(lot, top)
+	if err != nil {
+		return err
+	}
+
+	lot.Sell(top, bid)
+
+	err = holdFunds(ctx, tx, lot.ID, *bid.BidderID, bid.Price, false)
+	if err != nil {
+		return err
+	}
+
+	err = insertBid(ctx, tx, bid)
+	if err != nil {
+		return err
+	}
+
+	err = releaseHolds(ctx, tx, lot.ID, lot.WinnerID)
+	if err != nil {
+		return err
+	}
+
+	// The auction is over now rather than at the planned end time.
+	lot.EndAt = *bid.CreatedAt
+
+	err = finishLot(ctx, tx, lot)
+	if err != nil {
+		return err
+	}
+
+	events := append(entity.NewBidEvents(lot, []*entity.Bid{bid}, false), entity.NewStatusEvent(lot))
+
+	return notifyEvents(ctx, tx, events)
+}
+
+// GetUnsettled method for fetching the ids of sold lots which haven't been paid
+// for yet.
+func (r LotRepo) GetUnsettled() ([]int64, error) {
+	query := `
+		SELECT id FROM lots
+		WHERE settlement_status IN ($1, $2) AND destroyed_at IS NULL
+		ORDER BY end_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, entity.SettlementPending, entity.SettlementFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanIDs(rows)
+}
+
+// Settle method for paying for a sold lot. The lot row is locked and build is
+// called with it and the account numbers of the payer and the payee; an error
+// from it (e.g. the lot has already been settled) rolls the transaction back.
+// The payer's hold on the lot is turned into the payment and the settlement
+// status of the lot is written in the same transaction, so a settlement is
+// either done completely or not at all. If the payer can't cover the price the
+// lot is marked as failed instead. Both outcomes go to the history of the lot, a
+// payment which keeps failing only once.
+func (r LotRepo) Settle(lot *entity.Lot, build func(lot *entity.Lot, payer, payee string) (*entity.LedgerTransaction, error)) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	locked, err := lockLot(ctx, tx, lot.ID)
+	if err != nil {
+		return err
+	}
+	*lot = *locked
+
+	if lot.WinnerID == nil || lot.CreatorID == nil {
+		return entity.ErrEditConflict
+	}
+
+	payerID, payeeID := lot.Parties()
+
+	payer, err := accountID(ctx, tx, payerID)
+	if err != nil {
+		return err
+	}
+
+	payee, err := accountID(ctx, tx, payeeID)
+	if err != nil {
+		return err
+	}
+
+	t, err := build(lot, payer, payee)
+	if err != nil {
+		return err
+	}
+
+	// The payment is made in a savepoint, a failed one leaves the lot to be
+	// marked as failed.
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := entity.SettlementSettled
+	kind := entity.HistoryPaid
+
+	err = releaseHold(ctx, sp, lot.ID, payerID)
+	if err == nil {
+		err = postTransaction(ctx, sp, t)
+	}
+
+	switch {
+	case err == nil:
+		err = sp.Commit(ctx)
+		if err != nil {
+			return err
+		}
+	case errors.Is(err, entity.ErrInsufficientFunds):
+		err = sp.Rollback(ctx)
+		if err != nil {
+			return err
+		}
+		status = entity.SettlementFailed
+		kind = entity.HistoryPaymentFailed
+	default:
+		return err
+	}
+
+	if *lot.SettlementStatus != status {
+		err = addHistory(ctx, tx, lot.ID, kind, &payerID, &lot.EndPrice)
+		if err != nil {
+			return err
+		}
+	}
+
+	lot.SettlementStatus = &status
+
+	return settleLot(ctx, tx, lot)
+}
+
+// finishLot writes the outcome of a closed lot, a sale goes to the history of the
+// lot.
+func finishLot(ctx context.Context, tx pgx.Tx, lot *entity.Lot) error {
+	if lot.WinnerID != nil {
+		err := addHistory(ctx, tx, lot.ID, entity.HistorySold, lot.WinnerID, &lot.EndPrice)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE lots
+		SET status = $1, winner_id = $2, end_price = $3, end_at = $4, settlement_status = $5, version = version + 1,
+		updated_at = NOW()
+		WHERE id = $6
+		RETURNING version, updated_at`
+
+	args := []interface{}{
+		lot.Status,
+		lot.WinnerID,
+		lot.EndPrice,
+		lot.EndAt,
+		lot.SettlementStatus,
+		lot.ID,
+	}
+
+	return tx.QueryRow(ctx, query, args...).Scan(&lot.Version, &lot.UpdatedAt)
+}
+
+// scanLot reads a row selected with lotColumns into the lot.
+func scanLot(row pgx.Row, lot *entity.Lot) error {
+	var window, extension, interval *int
+	var floor *int64
+
+	err := row.Scan(
+		&lot.ID,
+		&lot.Status,
+		&lot.Type,
+		&lot.Title,
+		&lot.Description,
+		&lot.StartPrice,
+		&lot.EndPrice,
+		&lot.StepPrice,
+		&lot.ReservePrice,
+		&lot.ReserveMet,
+		&lot.BuyNowPrice,
+		&lot.CreatorID,
+		&lot.WinnerID,
+		&lot.StartAt,
+		&lot.EndAt,
+		&window,
+		&extension,
+		&floor,
+		&interval,
+		&lot.SettlementStatus,
+		&lot.CategoryID,
+		&lot.Tags,
+		&lot.Notify,
+		&lot.DestroyedAt,
+		&lot.Version,
+		&lot.CreatedAt,
+		&lot.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	lot.SoftClose = nil
+	if window != nil && extension != nil {
+		lot.SoftClose = &entity.SoftClose{Window: *window, Extension: *extension}
+	}
+
+	lot.Dutch = nil
+	if floor != nil && interval != nil {
+		lot.Dutch = &entity.Dutch{FloorPrice: *floor, Interval: *interval}
 	}
 
 	return nil
 }
+
+// softCloseArgs returns the soft_close_window and soft_close_extension column
+// values of the lot.
+func softCloseArgs(lot *entity.Lot) (window, extension *int) {
+	if lot.SoftClose == nil {
+		return nil, nil
+	}
+
+	return &lot.SoftClose.Window, &lot.SoftClose.Extension
+}
+
+// dutchArgs returns the floor_price and drop_interval column values of the lot.
+func dutchArgs(lot *entity.Lot) (floor *int64, interval *int) {
+	if lot.Dutch == nil {
+		return nil, nil
+	}
+
+	return &lot.Dutch.FloorPrice, &lot.Dutch.Interval
+}
+
+// htmlEscape returns the SQL expression escaping the HTML special characters of
+// the text the expression gives.
+func htmlEscape(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, so that the text is
+// matched as it is.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// scanIDs collects the id column of a resultset.
+func scanIDs(rows pgx.Rows) ([]int64, error) {
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
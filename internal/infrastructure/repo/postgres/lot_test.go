@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"oil portrait", "oil portrait"},
+		{"100%", `100\%`},
+		{"lot_1", `lot\_1`},
+		{`a\b`, `a\\b`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLotRepoSearchLanguage(t *testing.T) {
+	pg := testPostgres(t, 2)
+
+	creatorID := createTestUser(t, pg, 0)
+
+	r := NewLotRepo(pg)
+
+	insert := func(language, title string) int64 {
+		t.Helper()
+
+		now := time.Now()
+
+		lot := &entity.Lot{
+			Status:         entity.LotPending,
+			Type:           entity.LotEnglish,
+			Title:          title,
+			StartPrice:     100,
+			StepPrice:      10,
+			CreatorID:      &creatorID,
+			StartAt:        now.Add(time.Hour),
+			EndAt:          now.Add(2 * time.Hour),
+			Tags:           []string{},
+			SearchLanguage: language,
+		}
+
+		err := r.Insert(lot)
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+
+		t.Cleanup(func() {
+			pg.Pool.Exec(context.Background(), "DELETE FROM lots WHERE id = $1", lot.ID)
+		})
+
+		return lot.ID
+	}
+
+	german := insert("german", "Zwei Katzen am Fenster")
+	english := insert("english", "Two cats at the window")
+
+	search := func(query string) map[int64]string {
+		t.Helper()
+
+		lots, _, err := r.Search(entity.LotSearchQuery{
+			Query:   query,
+			Filters: entity.Filters{Page: 1, PageSize: 100, Sort: "rank", SortSafelist: []string{"rank"}},
+		})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", query, err)
+		}
+
+		found := map[int64]string{}
+		for _, lot := range lots {
+			found[lot.ID] = lot.Title
+		}
+
+		return found
+	}
+
+	// The German stemmer takes Katze and Katzen for the same word, the English
+	// one doesn't.
+	found := search("Katze")
+
+	if title, ok := found[german]; !ok || title != "Zwei <mark>Katzen</mark> am Fenster" {
+		t.Errorf("German lot found as %q (%v), want it highlighted", title, ok)
+	}
+
+	if _, ok := found[english]; ok {
+		t.Error("English lot found by a German word")
+	}
+
+	found = search("cat")
+
+	if _, ok := found[english]; !ok {
+		t.Error("English lot not found")
+	}
+}
@@ -0,0 +1,376 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetOverdue method for fetching the ids of sold lots which are due for a
+// second-chance offer: the winner hasn't paid for them by due (the end of the
+// payment deadline for the lots which ended then) or the open offer has expired
+// by now.
+func (r LotRepo) GetOverdue(due, now time.Time) ([]int64, error) {
+	query := `
+		SELECT id FROM lots
+		WHERE destroyed_at IS NULL AND ((settlement_status IN ($1, $2) AND end_at <= $3)
+			OR (settlement_status = $4 AND EXISTS (
+				SELECT 1 FROM offers WHERE offers.lot_id = lots.id AND offers.status = $5 AND offers.expires_at <= $6)))
+		ORDER BY end_at`
+
+	args := []interface{}{
+		entity.SettlementPending,
+		entity.SettlementFailed,
+		due,
+		entity.SettlementOffered,
+		entity.OfferOpen,
+		now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanIDs(rows)
+}
+
+// GetOffer method for fetching the second-chance offer of the lot made to the
+// bidder.
+func (r LotRepo) GetOffer(lotID, bidderID int64) (*entity.Offer, error) {
+	query := `
+		SELECT ` + offerColumns + `
+		FROM offers
+		WHERE lot_id = $1 AND bidder_id = $2
+		AND EXISTS (SELECT 1 FROM lots WHERE lots.id = offers.lot_id AND lots.destroyed_at IS NULL)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var offer entity.Offer
+
+	err := scanOffer(r.Pool.QueryRow(ctx, query, lotID, bidderID), &offer)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &offer, nil
+}
+
+// Reoffer method for moving an unpaid lot on to the next bidder. The lot row is
+// locked and check is called with it and its open offer (nil if there is none),
+// it is expected to close the offer. Without an offer the winner is the one who
+// hasn't paid and their hold on the lot is released. Then the lot is offered
+// until expiresAt to the highest bidder who hasn't been offered it yet, at the
+// price of their best bid; the offer is returned, nil if the lot is left without
+// a buyer. Every step goes to the history of the lot.
+func (r LotRepo) Reoffer(lot *entity.Lot, expiresAt time.Time, check func(lot *entity.Lot, current *entity.Offer) error) (offer *entity.Offer, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	locked, err := lockLot(ctx, tx, lot.ID)
+	if err != nil {
+		return nil, err
+	}
+	*lot = *locked
+
+	current, err := openOffer(ctx, tx, lot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = check(lot, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		err = updateOffer(ctx, tx, current)
+		if err != nil {
+			return nil, err
+		}
+
+		kind := entity.HistoryOfferExpired
+		if current.Status == entity.OfferDeclined {
+			kind = entity.HistoryOfferDeclined
+		}
+
+		err = addHistory(ctx, tx, lot.ID, kind, current.BidderID, &current.Price)
+	} else {
+		payer := lot.WinnerID
+		if lot.Reverse() {
+			payer = lot.CreatorID
+		}
+
+		err = releaseHold(ctx, tx, lot.ID, *lot.WinnerID)
+		if err == nil {
+			err = addHistory(ctx, tx, lot.ID, entity.HistoryPaymentOverdue, payer, &lot.EndPrice)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// On a reverse lot it is the creator who hasn't paid, there is nobody else
+	// to offer it to.
+	var bid *entity.Bid
+	if lot.BidderPays() {
+		bid, err = nextBid(ctx, tx, lot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	offer = lot.Offer(bid, expiresAt)
+
+	if offer != nil {
+		err = insertOffer(ctx, tx, offer)
+		if err == nil {
+			err = addHistory(ctx, tx, lot.ID, entity.HistoryOffered, offer.BidderID, &offer.Price)
+		}
+	} else {
+		err = addHistory(ctx, tx, lot.ID, entity.HistoryUnsold, nil, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return offer, settleLot(ctx, tx, lot)
+}
+
+// AcceptOffer method for buying a lot on a second-chance offer. The lot row is
+// locked and build is called with it, the offer of the bidder and the account
+// numbers of the bidder and the creator of the lot; it is expected to award the
+// lot to the bidder and return the payment, which is made at once. The offer,
+// the new winner and the settled lot are written in the same transaction, the
+// payment fails with ErrInsufficientFunds if the bidder can't cover it and the
+// offer is left open.
+func (r LotRepo) AcceptOffer(lot *entity.Lot, offer *entity.Offer, build func(lot *entity.Lot, offer *entity.Offer, payer, payee string) (*entity.LedgerTransaction, error)) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	locked, err := lockLot(ctx, tx, lot.ID)
+	if err != nil {
+		return err
+	}
+	*lot = *locked
+
+	query := `
+		SELECT ` + offerColumns + `
+		FROM offers
+		WHERE lot_id = $1 AND bidder_id = $2
+		AND EXISTS (SELECT 1 FROM lots WHERE lots.id = offers.lot_id AND lots.destroyed_at IS NULL)`
+
+	err = scanOffer(tx.QueryRow(ctx, query, lot.ID, offer.BidderID), offer)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return entity.ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if lot.CreatorID == nil {
+		return entity.ErrEditConflict
+	}
+
+	payer, err := accountID(ctx, tx, *offer.BidderID)
+	if err != nil {
+		return err
+	}
+
+	payee, err := accountID(ctx, tx, *lot.CreatorID)
+	if err != nil {
+		return err
+	}
+
+	t, err := build(lot, offer, payer, payee)
+	if err != nil {
+		return err
+	}
+
+	err = postTransaction(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	err = updateOffer(ctx, tx, offer)
+	if err != nil {
+		return err
+	}
+
+	err = addHistory(ctx, tx, lot.ID, entity.HistoryOfferAccepted, offer.BidderID, &offer.Price)
+	if err != nil {
+		return err
+	}
+
+	err = addHistory(ctx, tx, lot.ID, entity.HistoryPaid, offer.BidderID, &offer.Price)
+	if err != nil {
+		return err
+	}
+
+	status := entity.SettlementSettled
+	lot.SettlementStatus = &status
+
+	return settleLot(ctx, tx, lot)
+}
+
+const offerColumns = `id, lot_id, bidder_id, price, status, expires_at, created_at, updated_at`
+
+func scanOffer(row pgx.Row, offer *entity.Offer) error {
+	return row.Scan(
+		&offer.ID,
+		&offer.LotID,
+		&offer.BidderID,
+		&offer.Price,
+		&offer.Status,
+		&offer.ExpiresAt,
+		&offer.CreatedAt,
+		&offer.UpdatedAt,
+	)
+}
+
+// openOffer returns the open second-chance offer of the lot or nil if there is
+// none.
+func openOffer(ctx context.Context, tx pgx.Tx, lotID int64) (*entity.Offer, error) {
+	query := `
+		SELECT ` + offerColumns + `
+		FROM offers
+		WHERE lot_id = $1 AND status = $2`
+
+	var offer entity.Offer
+
+	err := scanOffer(tx.QueryRow(ctx, query, lotID, entity.OfferOpen), &offer)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &offer, nil
+}
+
+func insertOffer(ctx context.Context, tx pgx.Tx, offer *entity.Offer) error {
+	query := `
+		INSERT INTO offers (lot_id, bidder_id, price, status, expires_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	args := []interface{}{
+		offer.LotID,
+		offer.BidderID,
+		offer.Price,
+		offer.Status,
+		offer.ExpiresAt,
+	}
+
+	return tx.QueryRow(ctx, query, args...).Scan(&offer.ID, &offer.CreatedAt, &offer.UpdatedAt)
+}
+
+// updateOffer writes the status of the offer.
+func updateOffer(ctx context.Context, tx pgx.Tx, offer *entity.Offer) error {
+	query := "UPDATE offers SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at"
+
+	return tx.QueryRow(ctx, query, offer.Status, offer.ID).Scan(&offer.UpdatedAt)
+}
+
+// nextBid returns the best bid of the highest bidder on the lot other than the
+// winner and the bidders who have been offered the lot already, nil if there is
+// nobody left.
+func nextBid(ctx context.Context, tx pgx.Tx, lot *entity.Lot) (*entity.Bid, error) {
+	query := `
+		SELECT id, amount, price, lot_id, bidder_id, auto, created_at, updated_at
+		FROM (
+			SELECT DISTINCT ON (bidder_id) *
+			FROM bids
+			WHERE lot_id = $1 AND bidder_id <> $2
+				AND bidder_id NOT IN (SELECT bidder_id FROM offers WHERE lot_id = $1)
+			ORDER BY bidder_id, price DESC, id
+		) best
+		ORDER BY price DESC, id
+		LIMIT 1`
+
+	var bid entity.Bid
+
+	err := tx.QueryRow(ctx, query, lot.ID, lot.WinnerID).Scan(
+		&bid.ID,
+		&bid.Amount,
+		&bid.Price,
+		&bid.LotID,
+		&bid.BidderID,
+		&bid.Auto,
+		&bid.CreatedAt,
+		&bid.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &bid, nil
+}
+
+// settleLot writes the buyer, the price and the settlement status of the sold
+// lot.
+func settleLot(ctx context.Context, tx pgx.Tx, lot *entity.Lot) error {
+	query := `
+		UPDATE lots
+		SET winner_id = $1, end_price = $2, settlement_status = $3, version = version + 1, updated_at = NOW()
+		WHERE id = $4
+		RETURNING version, updated_at`
+
+	args := []interface{}{
+		lot.WinnerID,
+		lot.EndPrice,
+		lot.SettlementStatus,
+		lot.ID,
+	}
+
+	return tx.QueryRow(ctx, query, args...).Scan(&lot.Version, &lot.UpdatedAt)
+}
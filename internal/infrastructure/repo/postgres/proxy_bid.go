@@ -0,0 +1,242 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetProxy method for fetching the proxy bid of the bidder on the lot.
+func (r *BidRepo) GetProxy(lotID, bidderID int64) (*entity.ProxyBid, error) {
+	query := `
+		SELECT id, max_price, lot_id, bidder_id, created_at, updated_at
+		FROM proxy_bids
+		WHERE lot_id = $1 AND bidder_id = $2
+		AND EXISTS (SELECT 1 FROM lots WHERE lots.id = proxy_bids.lot_id AND lots.destroyed_at IS NULL)`
+
+	var proxy entity.ProxyBid
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.Pool.QueryRow(ctx, query, lotID, bidderID).Scan(
+		&proxy.ID,
+		&proxy.MaxPrice,
+		&proxy.LotID,
+		&proxy.BidderID,
+		&proxy.CreatedAt,
+		&proxy.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &proxy, nil
+}
+
+// SaveProxy method for creating or replacing the proxy bid of the bidder on the
+// lot. Like Insert it works on the locked lot: check is called with the lot and
+// its leading bid (nil if there are none) before the proxy is written and may
+// move lot.EndAt, then the proxies on the lot answer the leading bid and the
+// counter-bids are returned. The proxy holds the funds for its maximum, it fails
+// with ErrInsufficientFunds if they are not available; the holds of the bidders
+// left behind are released. The events of the counter-bids are sent in the same
+// transaction.
+func (r *BidRepo) SaveProxy(proxy *entity.ProxyBid, check func(lot *entity.Lot, top *entity.Bid) error) (counters []*entity.Bid, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	lot, err := lockLot(ctx, tx, proxy.LotID)
+	if err != nil {
+		return nil, err
+	}
+
+	top, err := topBid(ctx, tx, lot)
+	if err != nil {
+		return nil, err
+	}
+
+	endAt := lot.EndAt
+
+	err = check(lot, top)
+	if err != nil {
+		return nil, err
+	}
+
+	if lot.BidderPays() {
+		err = holdFunds(ctx, tx, lot.ID, *proxy.BidderID, proxy.MaxPrice, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Raising or lowering the maximum counts as a new proxy bid, so it takes a
+	// new set_seq, which decides a tie between two proxies with the same maximum.
+	query := `
+		INSERT INTO proxy_bids (max_price, lot_id, bidder_id) VALUES ($1, $2, $3)
+		ON CONFLICT (lot_id, bidder_id) DO UPDATE SET max_price = EXCLUDED.max_price,
+			set_seq = nextval('proxy_bids_set_seq'), updated_at = NOW()
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query, proxy.MaxPrice, proxy.LotID, proxy.BidderID).Scan(
+		&proxy.ID,
+		&proxy.CreatedAt,
+		&proxy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	counters, err = insertCounterBids(ctx, tx, lot, top)
+	if err != nil {
+		return nil, err
+	}
+
+	if lot.BidderPays() {
+		leader := top
+		if len(counters) > 0 {
+			leader = counters[len(counters)-1]
+		}
+
+		var keep *int64
+		if leader != nil {
+			keep = leader.BidderID
+		}
+
+		err = releaseHolds(ctx, tx, lot.ID, keep)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	extended := !lot.EndAt.Equal(endAt)
+	if extended {
+		err = extendLot(ctx, tx, lot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = notifyEvents(ctx, tx, entity.NewBidEvents(lot, counters, extended))
+	if err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// DeleteProxy method for removing the proxy bid of the bidder on the lot. Bids
+// already placed by the proxy stay in place, the hold goes down to the leading
+// bid if it is the bidder's and is released otherwise.
+func (r *BidRepo) DeleteProxy(lotID, bidderID int64) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	lot, err := lockLot(ctx, tx, lotID)
+	if err != nil {
+		return err
+	}
+
+	query := "DELETE FROM proxy_bids WHERE lot_id = $1 AND bidder_id = $2"
+
+	result, err := tx.Exec(ctx, query, lotID, bidderID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return entity.ErrRecordNotFound
+	}
+
+	if !lot.BidderPays() {
+		return nil
+	}
+
+	top, err := topBid(ctx, tx, lot)
+	if err != nil {
+		return err
+	}
+
+	if top != nil && *top.BidderID == bidderID {
+		return holdFunds(ctx, tx, lot.ID, bidderID, top.Price, false)
+	}
+
+	return releaseHold(ctx, tx, lot.ID, bidderID)
+}
+
+// getProxyBids returns the proxy bids on the lot ordered the way they compete:
+// the highest maximum first and the earliest one first among equal maximums.
+func getProxyBids(ctx context.Context, tx pgx.Tx, lotID int64) ([]*entity.ProxyBid, error) {
+	query := `
+		SELECT id, max_price, lot_id, bidder_id, created_at, updated_at
+		FROM proxy_bids
+		WHERE lot_id = $1
+		ORDER BY max_price DESC, set_seq`
+
+	rows, err := tx.Query(ctx, query, lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	proxies := []*entity.ProxyBid{}
+
+	for rows.Next() {
+		var proxy entity.ProxyBid
+
+		err := rows.Scan(
+			&proxy.ID,
+			&proxy.MaxPrice,
+			&proxy.LotID,
+			&proxy.BidderID,
+			&proxy.CreatedAt,
+			&proxy.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		proxies = append(proxies, &proxy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return proxies, nil
+}
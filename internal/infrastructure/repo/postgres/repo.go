@@ -6,16 +6,22 @@ import (
 
 // Create a Repo struct which wraps all repo.
 type Repo struct {
-	Users UserRepo
-	Lots  LotRepo
-	Bids  BidRepo
+	Users       UserRepo
+	Lots        LotRepo
+	Bids        BidRepo
+	Accounts    AccountRepo
+	Categories  CategoryRepo
+	Attachments AttachmentRepo
 }
 
 // For ease of use, we also add a NewRepo() method which returns a Repo struct
 func NewRepo(pg *postgres.Postgres) Repo {
 	return Repo{
-		Users: UserRepo{pg},
-		Lots:  LotRepo{pg},
-		Bids:  BidRepo{pg},
+		Users:       UserRepo{pg},
+		Lots:        LotRepo{pg},
+		Bids:        BidRepo{pg},
+		Accounts:    AccountRepo{pg},
+		Categories:  CategoryRepo{pg},
+		Attachments: AttachmentRepo{pg},
 	}
 }
@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetDestroyed method for fetching a page of the lots in the trash, together
+// with the pagination metadata of the whole list.
+func (r LotRepo) GetDestroyed(filters entity.Filters) ([]*entity.Lot, entity.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT `+lotColumns+`
+		FROM lots
+		WHERE destroyed_at IS NOT NULL
+		ORDER BY %s %s, id ASC
+		LIMIT $1 OFFSET $2`, filters.SortColumn(), filters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	totalRecords := 0
+
+	err := r.Pool.QueryRow(ctx, "SELECT count(*) FROM lots WHERE destroyed_at IS NOT NULL").Scan(&totalRecords)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	rows, err := r.Pool.Query(ctx, query, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	lots := []*entity.Lot{}
+
+	for rows.Next() {
+		var lot entity.Lot
+
+		err := scanLot(rows, &lot)
+		if err != nil {
+			return nil, entity.Metadata{}, err
+		}
+
+		lots = append(lots, &lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	metadata := entity.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return lots, metadata, nil
+}
+
+// Restore method for taking a lot out of the trash. A lot which isn't there is
+// not found.
+func (r LotRepo) Restore(id int64) (*entity.Lot, error) {
+	query := `
+		UPDATE lots SET destroyed_at = NULL, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND destroyed_at IS NOT NULL
+		RETURNING ` + lotColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var lot entity.Lot
+
+	err := scanLot(r.Pool.QueryRow(ctx, query, id), &lot)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, entity.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &lot, nil
+}
+
+// Purge method for deleting the lots which were moved to the trash before the
+// given time for good, their bids, offers and history go with them. It returns
+// the ids of the purged lots and their attachments, whose files are left for the
+// caller to remove from the storage.
+func (r LotRepo) Purge(before time.Time) (ids []int64, attachments []*entity.Attachment, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	query := `
+		SELECT id FROM lots
+		WHERE destroyed_at <= $1
+		ORDER BY id
+		FOR UPDATE`
+
+	rows, err := tx.Query(ctx, query, before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, err = scanIDs(rows)
+	rows.Close()
+	if err != nil || len(ids) == 0 {
+		return nil, nil, err
+	}
+
+	query = `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE lot_id = ANY($1)
+		ORDER BY lot_id, id`
+
+	rows, err = tx.Query(ctx, query, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var attachment entity.Attachment
+
+		err = scanAttachment(rows, &attachment)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		attachments = append(attachments, &attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// The bids, offers, history and attachments of the lots are deleted with
+	// them.
+	_, err = tx.Exec(ctx, "DELETE FROM lots WHERE id = ANY($1)", ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, attachments, nil
+}
@@ -0,0 +1,108 @@
+// Package storage implements the storages of the uploaded files.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local - keeps the uploaded files in a directory on the local filesystem and
+// serves them over HTTP, under the base URL.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal -.
+func NewLocal(dir, baseURL string) (*Local, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("storage - NewLocal - os.MkdirAll: %w", err)
+	}
+
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put - writing the file under the key. The file is written next to its place
+// first and moved there once complete, so a half-written file is never served.
+func (s *Local) Put(key string, r io.Reader) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Delete - removing the file under the key, a missing file is not an error.
+func (s *Local) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// URL - the address the file under the key is served from.
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// ServeHTTP - serving the file named by the last element of the request path.
+// There are no directories to list, a request for one gets 404.
+func (s *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, err := s.path(filepath.Base(r.URL.Path))
+	if err != nil || strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, path)
+}
+
+// path returns the place of the file under the key. The keys are plain file
+// names, anything which could reach outside the directory is refused.
+func (s *Local) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("storage - invalid key %q", key)
+	}
+
+	return filepath.Join(s.dir, key), nil
+}
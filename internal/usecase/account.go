@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+type AccountRepository interface {
+	GetByUser(userID int64) (*entity.Account, error)
+	GetEntries(accountID string) ([]*entity.LedgerEntry, error)
+	Post(t *entity.LedgerTransaction) error
+}
+
+// AccountUseCase -.
+type AccountUseCase struct {
+	repo AccountRepository
+}
+
+// NewAccountUseCase -.
+func NewAccountUseCase(r AccountRepository) *AccountUseCase {
+	return &AccountUseCase{
+		repo: r,
+	}
+}
+
+// Show - getting the account of the user.
+func (uc *AccountUseCase) Show(userID int64) (*entity.Account, error) {
+	account, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Entries - getting the ledger entries of the user's account, the latest first.
+func (uc *AccountUseCase) Entries(userID int64) ([]*entity.LedgerEntry, error) {
+	account, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.repo.GetEntries(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Deposit - paying money into the user's account.
+func (uc *AccountUseCase) Deposit(userID int64, transfer *entity.Transfer, adminID int64) (*entity.LedgerTransaction, error) {
+	account, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := entity.NewTransfer(entity.LedgerDeposit, entity.CashAccountID, account.ID, transfer.Amount, transfer.Reference, &adminID)
+
+	err = uc.repo.Post(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Withdraw - paying money out of the user's account. It fails with
+// ErrInsufficientFunds if the balance is too low.
+func (uc *AccountUseCase) Withdraw(userID int64, transfer *entity.Transfer, adminID int64) (*entity.LedgerTransaction, error) {
+	account, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := entity.NewTransfer(entity.LedgerWithdrawal, account.ID, entity.CashAccountID, transfer.Amount, transfer.Reference, &adminID)
+
+	err = uc.repo.Post(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders of the attachment image formats
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/ElOtro/auction-go/config"
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/thumbnail"
+)
+
+// maxImagePixels is the largest image (in pixels) a thumbnail is made of, a
+// small file can decode to a huge image.
+const maxImagePixels = 50_000_000
+
+type AttachmentRepository interface {
+	GetAll(lotIDs ...int64) ([]*entity.Attachment, error)
+	Get(lotID, id int64) (*entity.Attachment, error)
+	Insert(attachment *entity.Attachment) error
+	Delete(lotID, id int64) error
+}
+
+// BlobStorage keeps the files attached to the lots under the keys given to them.
+type BlobStorage interface {
+	Put(key string, r io.Reader) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+// AttachmentUseCase -.
+type AttachmentUseCase struct {
+	repo          AttachmentRepository
+	lotRepo       LotRepository
+	storage       BlobStorage
+	thumbnailSize int
+}
+
+// NewAttachmentUseCase -.
+func NewAttachmentUseCase(r AttachmentRepository, lr LotRepository, s BlobStorage, storage config.Storage) *AttachmentUseCase {
+	return &AttachmentUseCase{
+		repo:          r,
+		lotRepo:       lr,
+		storage:       s,
+		thumbnailSize: storage.ThumbnailSize,
+	}
+}
+
+// List - getting the attachments of a lot from store.
+func (uc *AttachmentUseCase) List(lotID int64) ([]*entity.Attachment, error) {
+	_, err := uc.lotRepo.Get(lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := uc.repo.GetAll(lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	setAttachmentURLs(uc.storage, attachments)
+
+	return attachments, nil
+}
+
+// Create - storing the file and attaching it to the lot on behalf of the user,
+// who has to be the creator of the lot or an admin. A thumbnail is made of an
+// image, the image has to decode for that. The stored files are removed again
+// if the attachment can't be saved.
+func (uc *AttachmentUseCase) Create(attachment *entity.Attachment, file io.ReadSeeker, user *entity.User) error {
+	lot, err := uc.lotRepo.Get(attachment.LotID)
+	if err != nil {
+		return err
+	}
+
+	err = canChangeLot(user, lot)
+	if err != nil {
+		return err
+	}
+
+	key, err := newStorageKey()
+	if err != nil {
+		return err
+	}
+
+	var thumb *bytes.Buffer
+	var thumbExt string
+
+	if attachment.Image() {
+		thumb, thumbExt, err = uc.thumbnail(file, attachment.ContentType)
+		if err != nil {
+			return err
+		}
+
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+	}
+
+	attachment.Key = key + entity.AttachmentTypes[attachment.ContentType]
+
+	err = uc.storage.Put(attachment.Key, file)
+	if err != nil {
+		return err
+	}
+
+	if thumb != nil {
+		thumbKey := key + "_thumb" + thumbExt
+		attachment.ThumbnailKey = &thumbKey
+
+		err = uc.storage.Put(thumbKey, thumb)
+		if err != nil {
+			removeBlobs(uc.storage, attachment)
+			return err
+		}
+	}
+
+	err = uc.repo.Insert(attachment)
+	if err != nil {
+		removeBlobs(uc.storage, attachment)
+		return err
+	}
+
+	setAttachmentURLs(uc.storage, []*entity.Attachment{attachment})
+
+	return nil
+}
+
+// Delete - detaching a file from the lot and removing it from the storage on
+// behalf of the user, who has to be the creator of the lot or an admin.
+func (uc *AttachmentUseCase) Delete(lotID, id int64, user *entity.User) error {
+	lot, err := uc.lotRepo.Get(lotID)
+	if err != nil {
+		return err
+	}
+
+	err = canChangeLot(user, lot)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := uc.repo.Get(lotID, id)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.Delete(lotID, id)
+	if err != nil {
+		return err
+	}
+
+	return removeBlobs(uc.storage, attachment)
+}
+
+// thumbnail decodes the image and makes its thumbnail. A JPEG thumbnail is made
+// of a JPEG image, a PNG one of the others. It returns the thumbnail and its
+// file extension.
+func (uc *AttachmentUseCase) thumbnail(file io.ReadSeeker, contentType string) (*bytes.Buffer, string, error) {
+	invalid := &entity.ValidationError{Errors: map[string]string{"file": "must be a valid image"}}
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, "", invalid
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, "", &entity.ValidationError{Errors: map[string]string{
+			"file": fmt.Sprintf("must not be larger than %d pixels", maxImagePixels),
+		}}
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, "", err
+	}
+
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, "", invalid
+	}
+
+	dst := thumbnail.New(src, uc.thumbnailSize)
+
+	var buf bytes.Buffer
+
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return &buf, ".jpg", err
+	}
+
+	err = png.Encode(&buf, dst)
+
+	return &buf, ".png", err
+}
+
+// removeBlobs removes the files of the attachment from the storage.
+func removeBlobs(storage BlobStorage, attachment *entity.Attachment) error {
+	err := storage.Delete(attachment.Key)
+
+	if attachment.ThumbnailKey != nil {
+		if terr := storage.Delete(*attachment.ThumbnailKey); err == nil {
+			err = terr
+		}
+	}
+
+	return err
+}
+
+// newStorageKey returns a random key for a new file in the storage.
+func newStorageKey() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// setAttachmentURLs sets the addresses the files of the attachments are served
+// from.
+func setAttachmentURLs(storage BlobStorage, attachments []*entity.Attachment) {
+	for _, a := range attachments {
+		a.URL = storage.URL(a.Key)
+
+		if a.ThumbnailKey != nil {
+			url := storage.URL(*a.ThumbnailKey)
+			a.ThumbnailURL = &url
+		}
+	}
+}
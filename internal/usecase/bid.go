@@ -1,12 +1,18 @@
 package usecase
 
 import (
+	"time"
+
 	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/internal/validator"
 )
 
 type BidRepository interface {
-	GetAll(lotID int64) ([]*entity.Bid, error)
-	Insert(bid *entity.Bid) error
+	GetAll(lotID int64, bidderID *int64) ([]*entity.Bid, error)
+	Insert(bid *entity.Bid, check func(lot *entity.Lot, price int64) error) ([]*entity.Bid, error)
+	GetProxy(lotID, bidderID int64) (*entity.ProxyBid, error)
+	SaveProxy(proxy *entity.ProxyBid, check func(lot *entity.Lot, top *entity.Bid) error) ([]*entity.Bid, error)
+	DeleteProxy(lotID, bidderID int64) error
 }
 
 // BidUseCase -.
@@ -23,22 +29,152 @@ func NewBidUseCase(r BidRepository, lr LotRepository) *BidUseCase {
 	}
 }
 
-// History - getting translate history from store.
-func (uc *BidUseCase) List(lotID int64) ([]*entity.Bid, error) {
-	companies, err := uc.repo.GetAll(lotID)
+// List - getting the bids on the lot from store. While the bids on a sealed-bid
+// lot are hidden the user only gets their own bid.
+func (uc *BidUseCase) List(lot *entity.Lot, userID int64) ([]*entity.Bid, error) {
+	var bidderID *int64
+	if lot.BidsHidden(time.Now()) {
+		bidderID = &userID
+	}
+
+	bids, err := uc.repo.GetAll(lot.ID, bidderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+// Create - creating a bid in store. The bid is rejected with ErrAuctionNotStarted
+// or ErrAuctionClosed unless the lot is published and now is inside its window,
+// with ErrStalePrice if the bidder's view of the price is out of date and with
+// ErrInsufficientFunds if the bidder can't cover the price. The bidder may ask
+// either for a target price or for the amount to raise the price by; without
+// both the price is raised by one step. On a reverse lot the price goes down
+// instead. The checks run while the lot is locked, so they can't race with other
+// bids. A bid within the soft-close window of the lot extends the auction in the
+// same transaction. The counter-bids placed by the proxy bids in answer are
+// returned and, like the bid itself, published to the subscribers of the lot.
+//
+// A bid on a sealed-bid lot is made blind: the bidder asks for a price (or for
+// the amount above the start price) and the bid replaces their earlier one.
+func (uc *BidUseCase) Create(bid *entity.Bid) ([]*entity.Bid, error) {
+	counters, err := uc.repo.Insert(bid, func(lot *entity.Lot, price int64) error {
+		now := time.Now()
+
+		if lot.Type == entity.LotDutch {
+			return entity.ErrWrongAuctionType
+		}
+
+		err := lot.Biddable(now)
+		if err != nil {
+			return err
+		}
+
+		if lot.Sealed() {
+			return priceSealedBid(bid, lot)
+		}
+
+		if bid.LastPrice != nil && *bid.LastPrice != price {
+			return entity.ErrStalePrice
+		}
+
+		switch {
+		case bid.Price != 0:
+			bid.Amount = bid.Price - price
+			if lot.Reverse() {
+				bid.Amount = -bid.Amount
+			}
+		case bid.Amount == 0:
+			bid.Amount = lot.StepPrice
+		}
+
+		bid.Price = price + bid.Amount
+		if lot.Reverse() {
+			bid.Price = price - bid.Amount
+		}
+
+		v := validator.New()
+		if entity.ValidateBidPrice(v, bid, lot, price); !v.Valid() {
+			return &entity.ValidationError{Errors: v.Errors}
+		}
+
+		lot.Extend(now)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// ShowProxy - getting the proxy bid of the bidder on the lot.
+func (uc *BidUseCase) ShowProxy(lotID, bidderID int64) (*entity.ProxyBid, error) {
+	proxy, err := uc.repo.GetProxy(lotID, bidderID)
 	if err != nil {
 		return nil, err
 	}
 
-	return companies, nil
+	return proxy, nil
 }
 
-// Create - creating a lot in store.
-func (uc *BidUseCase) Create(bid *entity.Bid) error {
-	err := uc.repo.Insert(bid)
+// SetProxy - creating or replacing the proxy bid of the bidder on the lot. The
+// proxies on the lot bid against each other straight away, the counter-bids are
+// returned. Like a bid, a proxy set within the soft-close window extends the
+// auction.
+func (uc *BidUseCase) SetProxy(proxy *entity.ProxyBid) ([]*entity.Bid, error) {
+	counters, err := uc.repo.SaveProxy(proxy, func(lot *entity.Lot, top *entity.Bid) error {
+		now := time.Now()
+
+		if lot.Type == entity.LotDutch || lot.Sealed() || lot.Reverse() {
+			return entity.ErrWrongAuctionType
+		}
+
+		err := lot.Biddable(now)
+		if err != nil {
+			return err
+		}
+
+		v := validator.New()
+		if entity.ValidateProxyBidPrice(v, proxy, lot, top); !v.Valid() {
+			return &entity.ValidationError{Errors: v.Errors}
+		}
+
+		lot.Extend(now)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// DeleteProxy - removing the proxy bid of the bidder on the lot.
+func (uc *BidUseCase) DeleteProxy(lotID, bidderID int64) error {
+	err := uc.repo.DeleteProxy(lotID, bidderID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// priceSealedBid fills in the price of a bid on a sealed-bid lot. The amount of
+// such a bid is counted from the start price, the other bids are not known.
+func priceSealedBid(bid *entity.Bid, lot *entity.Lot) error {
+	if bid.Price == 0 && bid.Amount != 0 {
+		bid.Price = lot.StartPrice + bid.Amount
+	}
+	bid.Amount = bid.Price - lot.StartPrice
+
+	v := validator.New()
+	if entity.ValidateSealedBidPrice(v, bid, lot); !v.Valid() {
+		return &entity.ValidationError{Errors: v.Errors}
+	}
+
+	return nil
+}
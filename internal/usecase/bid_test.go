@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+// stubBidRepo runs the check of Insert against a fixed lot and price, as the
+// repository does with the locked lot.
+type stubBidRepo struct {
+	BidRepository
+	lot   entity.Lot
+	price int64
+}
+
+func (r *stubBidRepo) Insert(bid *entity.Bid, check func(lot *entity.Lot, price int64) error) ([]*entity.Bid, error) {
+	lot := r.lot
+	if err := check(&lot, r.price); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func TestBidUseCaseCreate(t *testing.T) {
+	now := time.Now()
+
+	lot := func(typ entity.LotType) entity.Lot {
+		return entity.Lot{
+			Type:      typ,
+			Status:    entity.LotPublished,
+			StepPrice: 100,
+			StartAt:   now.Add(-time.Hour),
+			EndAt:     now.Add(time.Hour),
+		}
+	}
+
+	price := func(p int64) *int64 { return &p }
+
+	tests := []struct {
+		name       string
+		lot        entity.Lot
+		bid        entity.Bid
+		wantAmount int64
+		wantPrice  int64
+		wantErr    error
+		invalid    bool
+	}{
+		{
+			name:       "step price by default",
+			lot:        lot(entity.LotEnglish),
+			bid:        entity.Bid{},
+			wantAmount: 100,
+			wantPrice:  1100,
+		},
+		{
+			name:       "amount",
+			lot:        lot(entity.LotEnglish),
+			bid:        entity.Bid{Amount: 300},
+			wantAmount: 300,
+			wantPrice:  1300,
+		},
+		{
+			name:       "target price",
+			lot:        lot(entity.LotEnglish),
+			bid:        entity.Bid{Price: 1500},
+			wantAmount: 500,
+			wantPrice:  1500,
+		},
+		{
+			name:    "target price below the next step",
+			lot:     lot(entity.LotEnglish),
+			bid:     entity.Bid{Price: 1050},
+			invalid: true,
+		},
+		{
+			name:       "reverse step price by default",
+			lot:        lot(entity.LotReverse),
+			bid:        entity.Bid{},
+			wantAmount: 100,
+			wantPrice:  900,
+		},
+		{
+			name:       "reverse target price",
+			lot:        lot(entity.LotReverse),
+			bid:        entity.Bid{Price: 700},
+			wantAmount: 300,
+			wantPrice:  700,
+		},
+		{
+			name:    "reverse target price above the current price",
+			lot:     lot(entity.LotReverse),
+			bid:     entity.Bid{Price: 1200},
+			invalid: true,
+		},
+		{
+			name:       "last price still current",
+			lot:        lot(entity.LotEnglish),
+			bid:        entity.Bid{LastPrice: price(1000)},
+			wantAmount: 100,
+			wantPrice:  1100,
+		},
+		{
+			name:    "last price moved on",
+			lot:     lot(entity.LotEnglish),
+			bid:     entity.Bid{Price: 1100, LastPrice: price(900)},
+			wantErr: entity.ErrStalePrice,
+		},
+		{
+			name:    "dutch lot",
+			lot:     lot(entity.LotDutch),
+			bid:     entity.Bid{},
+			wantErr: entity.ErrWrongAuctionType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewBidUseCase(&stubBidRepo{lot: tt.lot, price: 1000}, nil)
+
+			bid := tt.bid
+			_, err := uc.Create(&bid)
+
+			var validationErr *entity.ValidationError
+			switch {
+			case tt.invalid:
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("got error %v, want a validation error", err)
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if bid.Amount != tt.wantAmount || bid.Price != tt.wantPrice {
+				t.Errorf("got amount %d and price %d, want %d and %d", bid.Amount, bid.Price, tt.wantAmount, tt.wantPrice)
+			}
+		})
+	}
+}
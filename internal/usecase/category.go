@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+type CategoryRepository interface {
+	GetAll() ([]*entity.Category, error)
+	Get(id int64) (*entity.Category, error)
+	InSubtree(root, id int64) (bool, error)
+	Insert(category *entity.Category) error
+	Update(category *entity.Category) error
+	Delete(id int64) error
+}
+
+// CategoryUseCase -.
+type CategoryUseCase struct {
+	repo CategoryRepository
+}
+
+// NewCategoryUseCase -.
+func NewCategoryUseCase(r CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{
+		repo: r,
+	}
+}
+
+// List - getting all categories from store.
+func (uc *CategoryUseCase) List() ([]*entity.Category, error) {
+	categories, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// Show - getting a category from store.
+func (uc *CategoryUseCase) Show(id int64) (*entity.Category, error) {
+	category, err := uc.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// Create - creating a category in store.
+func (uc *CategoryUseCase) Create(category *entity.Category) error {
+	err := uc.repo.Insert(category)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update - updating a category to store. A category can't be moved under one of
+// its own subcategories, that would make a cycle.
+func (uc *CategoryUseCase) Update(category *entity.Category) error {
+	if category.ParentID != nil {
+		cycle, err := uc.repo.InSubtree(category.ID, *category.ParentID)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return &entity.ValidationError{Errors: map[string]string{
+				"parent_id": "must not be the category itself or one of its subcategories",
+			}}
+		}
+	}
+
+	err := uc.repo.Update(category)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete - deleting a category from store. It fails with ErrCategoryNotEmpty if
+// the category has subcategories.
+func (uc *CategoryUseCase) Delete(id int64) error {
+	err := uc.repo.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
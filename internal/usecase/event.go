@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+// EventPublisher sends the lot events to the subscribers of the lot, wherever
+// they are connected.
+type EventPublisher interface {
+	Publish(event *entity.LotEvent)
+}
+
+// EventBroker delivers the lot events to the subscribers connected here.
+type EventBroker interface {
+	Subscribe(lotID, lastID int64) ([]*entity.LotEvent, <-chan *entity.LotEvent, func())
+}
+
+// EventUseCase -.
+type EventUseCase struct {
+	broker  EventBroker
+	lotRepo LotRepository
+}
+
+// NewEventUseCase -.
+func NewEventUseCase(b EventBroker, lr LotRepository) *EventUseCase {
+	return &EventUseCase{
+		broker:  b,
+		lotRepo: lr,
+	}
+}
+
+// Subscribe - subscribing to the events of the lot. The events after lastID which
+// are still kept are returned first, the new ones come through the channel until
+// cancel is called.
+func (uc *EventUseCase) Subscribe(lotID, lastID int64) ([]*entity.LotEvent, <-chan *entity.LotEvent, func(), error) {
+	_, err := uc.lotRepo.Get(lotID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	backlog, events, cancel := uc.broker.Subscribe(lotID, lastID)
+
+	return backlog, events, cancel, nil
+}
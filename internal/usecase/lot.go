@@ -1,37 +1,96 @@
 package usecase
 
 import (
+	"errors"
+	"time"
+
+	"github.com/ElOtro/auction-go/config"
 	"github.com/ElOtro/auction-go/internal/entity"
 )
 
 type LotRepository interface {
-	GetAll() ([]*entity.Lot, error)
+	GetAll(filters entity.LotFilters) ([]*entity.Lot, entity.Metadata, error)
 	Get(id int64) (*entity.Lot, error)
 	Insert(lot *entity.Lot) error
 	Update(lot *entity.Lot) error
-	Delete(id int64) error
+	Delete(id int64, check func(lot *entity.Lot, top *entity.Bid) error) error
+	Publish(now time.Time) ([]int64, error)
+	GetExpired(now time.Time) ([]int64, error)
+	Finish(lot *entity.Lot, check func(lot *entity.Lot) error) error
+	Buy(lot *entity.Lot, bid *entity.Bid, check func(lot *entity.Lot, top *entity.Bid) error) error
+	GetUnsettled() ([]int64, error)
+	Settle(lot *entity.Lot, build func(lot *entity.Lot, payer, payee string) (*entity.LedgerTransaction, error)) error
+	GetOverdue(due, now time.Time) ([]int64, error)
+	GetOffer(lotID, bidderID int64) (*entity.Offer, error)
+	Reoffer(lot *entity.Lot, expiresAt time.Time, check func(lot *entity.Lot, current *entity.Offer) error) (*entity.Offer, error)
+	AcceptOffer(lot *entity.Lot, offer *entity.Offer, build func(lot *entity.Lot, offer *entity.Offer, payer, payee string) (*entity.LedgerTransaction, error)) error
+	GetHistory(lotID int64) ([]*entity.LotHistory, error)
+	Search(query entity.LotSearchQuery) ([]*entity.LotSearch, entity.Metadata, error)
+	GetDestroyed(filters entity.Filters) ([]*entity.Lot, entity.Metadata, error)
+	Restore(id int64) (*entity.Lot, error)
+	Purge(before time.Time) ([]int64, []*entity.Attachment, error)
 }
 
 // LotUseCase -.
 type LotUseCase struct {
-	repo LotRepository
+	repo            LotRepository
+	attachments     AttachmentRepository
+	storage         BlobStorage
+	events          EventPublisher
+	buyNowShare     float64
+	commission      float64
+	paymentDeadline time.Duration
+	offerExpiry     time.Duration
+	searchLanguage  string
+	trashRetention  time.Duration
 }
 
 // NewLotUseCase -.
-func NewLotUseCase(r LotRepository) *LotUseCase {
+func NewLotUseCase(r LotRepository, ar AttachmentRepository, s BlobStorage, events EventPublisher, auction config.Auction, search config.Search, trash config.Trash) *LotUseCase {
 	return &LotUseCase{
-		repo: r,
+		repo:            r,
+		attachments:     ar,
+		storage:         s,
+		events:          events,
+		buyNowShare:     auction.BuyNowShare,
+		commission:      auction.Commission,
+		paymentDeadline: auction.PaymentDeadline,
+		offerExpiry:     auction.OfferExpiry,
+		searchLanguage:  search.Language,
+		trashRetention:  trash.Retention,
 	}
 }
 
-// List - getting all lots from store.
-func (uc *LotUseCase) List() ([]*entity.Lot, error) {
-	lots, err := uc.repo.GetAll()
+// List - getting a page of the lots matching the filters from store.
+func (uc *LotUseCase) List(filters entity.LotFilters) ([]*entity.Lot, entity.Metadata, error) {
+	lots, metadata, err := uc.repo.GetAll(filters)
 	if err != nil {
-		return nil, err
+		return nil, entity.Metadata{}, err
+	}
+
+	now := time.Now()
+	for _, lot := range lots {
+		setAskingPrice(lot, now)
+		uc.setPaymentDueAt(lot)
 	}
 
-	return lots, nil
+	err = uc.setAttachments(lots...)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	return lots, metadata, nil
+}
+
+// Search - finding the lots whose title or description match the query, the
+// best match first. Every lot is matched in the language it was indexed in.
+func (uc *LotUseCase) Search(query entity.LotSearchQuery) ([]*entity.LotSearch, entity.Metadata, error) {
+	lots, metadata, err := uc.repo.Search(query)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	return lots, metadata, nil
 }
 
 // Show - getting a lot from store.
@@ -41,11 +100,39 @@ func (uc *LotUseCase) Show(id int64) (*entity.Lot, error) {
 		return nil, err
 	}
 
+	setAskingPrice(lot, time.Now())
+	uc.setPaymentDueAt(lot)
+
+	err = uc.setAttachments(lot)
+	if err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+// Edit - getting the lot the user is about to change, a PolicyError unless they
+// may (see Update). The check is made before the changes are read, Update makes
+// it again.
+func (uc *LotUseCase) Edit(id int64, user *entity.User) (*entity.Lot, error) {
+	lot, err := uc.Show(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = canChangeLot(user, lot)
+	if err != nil {
+		return nil, err
+	}
+
 	return lot, nil
 }
 
-// Create - creating a lot in store.
+// Create - creating a lot in store. The lot is indexed for the search in the
+// configured search language.
 func (uc *LotUseCase) Create(lot *entity.Lot) error {
+	lot.SearchLanguage = uc.searchLanguage
+
 	err := uc.repo.Insert(lot)
 	if err != nil {
 		return err
@@ -54,9 +141,31 @@ func (uc *LotUseCase) Create(lot *entity.Lot) error {
 	return nil
 }
 
-// Update - updating a lot to store.
-func (uc *LotUseCase) Update(lot *entity.Lot) error {
-	err := uc.repo.Update(lot)
+// Update - updating a lot to store on behalf of the user. Only the creator of
+// the lot or an admin may, and the prices and times of the lot are frozen once
+// bidding has started (a PolicyError otherwise). It fails with ErrEditConflict
+// if the lot has changed since it was read.
+func (uc *LotUseCase) Update(lot *entity.Lot, user *entity.User) error {
+	stored, err := uc.repo.Get(lot.ID)
+	if err != nil {
+		return err
+	}
+
+	err = canChangeLot(user, stored)
+	if err != nil {
+		return err
+	}
+
+	if stored.Version != lot.Version {
+		return entity.ErrEditConflict
+	}
+
+	err = checkFrozenLot(stored, lot, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.Update(lot)
 	if err != nil {
 		return err
 	}
@@ -64,12 +173,414 @@ func (uc *LotUseCase) Update(lot *entity.Lot) error {
 	return nil
 }
 
-// Delete - deleting a lot from store.
-func (uc *LotUseCase) Delete(id int64) error {
-	err := uc.repo.Delete(id)
+// Delete - moving a lot to the trash on behalf of the user, it can be restored
+// from there until it is purged. Only the creator of the lot or an admin may,
+// and not while its auction is running, there are bids on it or its sale is
+// waiting for the payment (a PolicyError otherwise).
+func (uc *LotUseCase) Delete(id int64, user *entity.User) error {
+	lot, err := uc.repo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	err = canChangeLot(user, lot)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.Delete(id, func(lot *entity.Lot, top *entity.Bid) error {
+		return checkDeletableLot(lot, top, time.Now())
+	})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Trash - getting a page of the lots in the trash from store.
+func (uc *LotUseCase) Trash(filters entity.Filters) ([]*entity.Lot, entity.Metadata, error) {
+	lots, metadata, err := uc.repo.GetDestroyed(filters)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	err = uc.setAttachments(lots...)
+	if err != nil {
+		return nil, entity.Metadata{}, err
+	}
+
+	return lots, metadata, nil
+}
+
+// Restore - taking a lot out of the trash. It fails with ErrRecordNotFound if
+// the lot isn't there.
+func (uc *LotUseCase) Restore(id int64) (*entity.Lot, error) {
+	lot, err := uc.repo.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+
+	setAskingPrice(lot, time.Now())
+	uc.setPaymentDueAt(lot)
+
+	err = uc.setAttachments(lot)
+	if err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+// Purge - deleting the lots which have been in the trash for longer than the
+// retention period for good, with the files of their attachments. It returns the
+// ids of the purged lots; they are gone even if removing a file fails.
+func (uc *LotUseCase) Purge(now time.Time) ([]int64, error) {
+	ids, attachments, err := uc.repo.Purge(now.Add(-uc.trashRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, a := range attachments {
+		if err := removeBlobs(uc.storage, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return ids, errors.Join(errs...)
+}
+
+// Publish - opening pending lots whose start time has passed. Every opened lot is
+// announced to its subscribers.
+func (uc *LotUseCase) Publish(now time.Time) ([]int64, error) {
+	ids, err := uc.repo.Publish(now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		uc.events.Publish(entity.NewStatusEvent(&entity.Lot{ID: id, Status: entity.LotPublished}))
+	}
+
+	return ids, nil
+}
+
+// Expired - getting ids of published lots which are due to be closed.
+func (uc *LotUseCase) Expired(now time.Time) ([]int64, error) {
+	ids, err := uc.repo.GetExpired(now)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Finish - closing a lot and picking the highest bidder as the winner. It fails
+// with ErrEditConflict if the lot is no longer due to be closed: it has already
+// been closed or a late bid has moved its end time. The outcome is published to
+// the subscribers of the lot.
+func (uc *LotUseCase) Finish(id int64) (*entity.Lot, error) {
+	lot := &entity.Lot{ID: id}
+
+	err := uc.repo.Finish(lot, func(lot *entity.Lot) error {
+		if lot.Status != entity.LotPublished || time.Now().Before(lot.EndAt) {
+			return entity.ErrEditConflict
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.events.Publish(entity.NewStatusEvent(lot))
+
+	return lot, nil
+}
+
+// Buy - buying a lot at its buy-now price. The lot is closed at once with the
+// buyer as the winner and the purchase is recorded as the final bid. It fails
+// with ErrBuyNowUnavailable if the lot has no buy-now price or the bids have
+// already reached the configured share of it.
+func (uc *LotUseCase) Buy(id, buyerID int64) (*entity.Lot, *entity.Bid, error) {
+	lot := &entity.Lot{ID: id}
+	bid := &entity.Bid{LotID: id, BidderID: &buyerID}
+
+	err := uc.repo.Buy(lot, bid, func(lot *entity.Lot, top *entity.Bid) error {
+		err := lot.Biddable(time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !lot.BuyNowAvailable(top, uc.buyNowShare) {
+			return entity.ErrBuyNowUnavailable
+		}
+
+		bid.Price = *lot.BuyNowPrice
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lot, err = uc.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lot, bid, nil
+}
+
+// Accept - accepting the current asking price of a Dutch lot. The first bidder
+// to accept wins: the lot is closed at once and the acceptance is recorded as
+// the final bid.
+func (uc *LotUseCase) Accept(id, bidderID int64) (*entity.Lot, *entity.Bid, error) {
+	lot := &entity.Lot{ID: id}
+	bid := &entity.Bid{LotID: id, BidderID: &bidderID}
+
+	err := uc.repo.Buy(lot, bid, func(lot *entity.Lot, top *entity.Bid) error {
+		now := time.Now()
+
+		if lot.Type != entity.LotDutch {
+			return entity.ErrWrongAuctionType
+		}
+
+		err := lot.Biddable(now)
+		if err != nil {
+			return err
+		}
+
+		bid.Price = lot.DutchPrice(now)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lot, err = uc.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lot, bid, nil
+}
+
+// Unsettled - getting ids of sold lots which haven't been paid for yet.
+func (uc *LotUseCase) Unsettled() ([]int64, error) {
+	ids, err := uc.repo.GetUnsettled()
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Settle - paying for a sold lot: the winner's hold becomes the payment and the
+// creator is paid the price less the platform commission (on a reverse lot the
+// creator pays the winner). It fails with ErrEditConflict if the lot has been
+// settled already, so running it again never charges twice, or the payment
+// deadline has passed. If the payer can't cover the price the lot is marked as
+// failed and tried again later.
+func (uc *LotUseCase) Settle(id int64) (*entity.Lot, error) {
+	lot := &entity.Lot{ID: id}
+
+	err := uc.repo.Settle(lot, func(lot *entity.Lot, payer, payee string) (*entity.LedgerTransaction, error) {
+		if !lot.AwaitingPayment() || lot.PaymentOverdue(time.Now(), uc.paymentDeadline) {
+			return nil, entity.ErrEditConflict
+		}
+
+		return entity.NewSettlement(lot, payer, payee, uc.commission), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+// Overdue - getting ids of sold lots whose winner hasn't paid within the payment
+// deadline or whose second-chance offer has expired.
+func (uc *LotUseCase) Overdue(now time.Time) ([]int64, error) {
+	ids, err := uc.repo.GetOverdue(now.Add(-uc.paymentDeadline), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Reoffer - offering an unpaid lot to the next bidder: once the winner has missed
+// the payment deadline, or the open second-chance offer has expired, the lot is
+// offered to the next-highest bidder at the price of their bid. The offer is nil
+// if there is nobody left and the lot stays unsold. It fails with
+// ErrEditConflict if the lot is no longer due for it.
+func (uc *LotUseCase) Reoffer(id int64) (*entity.Lot, *entity.Offer, error) {
+	lot := &entity.Lot{ID: id}
+
+	offer, err := uc.repo.Reoffer(lot, time.Now().Add(uc.offerExpiry), func(lot *entity.Lot, current *entity.Offer) error {
+		now := time.Now()
+
+		if current != nil {
+			if current.Open(now) {
+				return entity.ErrEditConflict
+			}
+			current.Status = entity.OfferExpired
+
+			return nil
+		}
+
+		if !lot.PaymentOverdue(now, uc.paymentDeadline) {
+			return entity.ErrEditConflict
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lot, offer, nil
+}
+
+// ShowOffer - getting the second-chance offer of a lot made to the bidder.
+func (uc *LotUseCase) ShowOffer(id, bidderID int64) (*entity.Offer, error) {
+	offer, err := uc.repo.GetOffer(id, bidderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// AcceptOffer - buying a lot on the second-chance offer made to the bidder. The
+// bidder becomes the winner and pays the price of the offer at once, the lot is
+// settled. It fails with ErrOfferClosed if the offer is no longer open and with
+// ErrInsufficientFunds if the bidder can't pay, the offer stays open then.
+func (uc *LotUseCase) AcceptOffer(id, bidderID int64) (*entity.Lot, *entity.Offer, error) {
+	lot := &entity.Lot{ID: id}
+	offer := &entity.Offer{LotID: id, BidderID: &bidderID}
+
+	err := uc.repo.AcceptOffer(lot, offer, func(lot *entity.Lot, offer *entity.Offer, payer, payee string) (*entity.LedgerTransaction, error) {
+		if !offer.Open(time.Now()) {
+			return nil, entity.ErrOfferClosed
+		}
+
+		lot.Award(offer)
+
+		return entity.NewSettlement(lot, payer, payee, uc.commission), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uc.events.Publish(entity.NewStatusEvent(lot))
+
+	return lot, offer, nil
+}
+
+// DeclineOffer - turning down the second-chance offer made to the bidder, the lot
+// is offered to the next bidder at once. It fails with ErrOfferClosed if the
+// offer is no longer open.
+func (uc *LotUseCase) DeclineOffer(id, bidderID int64) (*entity.Offer, error) {
+	offer, err := uc.repo.GetOffer(id, bidderID)
+	if err != nil {
+		return nil, err
+	}
+
+	lot := &entity.Lot{ID: id}
+
+	_, err = uc.repo.Reoffer(lot, time.Now().Add(uc.offerExpiry), func(lot *entity.Lot, current *entity.Offer) error {
+		if current == nil || current.ID != offer.ID || !current.Open(time.Now()) {
+			return entity.ErrOfferClosed
+		}
+
+		current.Status = entity.OfferDeclined
+		offer.Status = entity.OfferDeclined
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// History - getting the history of a lot after the end of its auction on
+// behalf of the user, who has to be one of the parties to the sale or an admin.
+func (uc *LotUseCase) History(id int64, user *entity.User) ([]*entity.LotHistory, error) {
+	lot, err := uc.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = uc.repo.GetOffer(id, user.ID)
+	if err != nil && !errors.Is(err, entity.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = canSeeHistory(user, lot, err == nil)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := uc.repo.GetHistory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// setPaymentDueAt works out the time the winner has to pay for a sold lot by.
+func (uc *LotUseCase) setPaymentDueAt(lot *entity.Lot) {
+	if !lot.AwaitingPayment() {
+		return
+	}
+
+	due := lot.EndAt.Add(uc.paymentDeadline)
+	lot.PaymentDueAt = &due
+}
+
+// setAttachments embeds the attachments in the lots, fetched together for all
+// of them.
+func (uc *LotUseCase) setAttachments(lots ...*entity.Lot) error {
+	if len(lots) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(lots))
+	byID := make(map[int64]*entity.Lot, len(lots))
+
+	for _, lot := range lots {
+		ids = append(ids, lot.ID)
+		byID[lot.ID] = lot
+	}
+
+	attachments, err := uc.attachments.GetAll(ids...)
+	if err != nil {
+		return err
+	}
+
+	setAttachmentURLs(uc.storage, attachments)
+
+	for _, a := range attachments {
+		lot := byID[a.LotID]
+		lot.Attachments = append(lot.Attachments, a)
+	}
+
+	return nil
+}
+
+// setAskingPrice works out the price an open Dutch lot can be accepted at.
+func setAskingPrice(lot *entity.Lot, now time.Time) {
+	if lot.Type != entity.LotDutch || lot.Status != entity.LotPublished {
+		return
+	}
+
+	price := lot.DutchPrice(now)
+	lot.AskingPrice = &price
+}
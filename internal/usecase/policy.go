@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+// canChangeLot checks that the user may change the lot, delete it or its
+// attachments: only the creator of the lot and the admins may.
+func canChangeLot(user *entity.User, lot *entity.Lot) error {
+	if user.IsAdmin() || (lot.CreatorID != nil && *lot.CreatorID == user.ID) {
+		return nil
+	}
+
+	return &entity.PolicyError{Reason: "only the creator of the lot or an admin can change it"}
+}
+
+// canSeeHistory checks that the user may see the history of the lot: only the
+// parties to the sale may, that is the creator, the winner and the bidders the
+// lot has been offered to, and the admins.
+func canSeeHistory(user *entity.User, lot *entity.Lot, offered bool) error {
+	if offered || user.IsAdmin() {
+		return nil
+	}
+
+	for _, id := range []*int64{lot.CreatorID, lot.WinnerID} {
+		if id != nil && *id == user.ID {
+			return nil
+		}
+	}
+
+	return &entity.PolicyError{Reason: "only the parties to the sale of the lot or an admin can see its history"}
+}
+
+// checkDeletableLot checks that the lot can go to the trash with its leading bid
+// (nil if there are none). Like its terms, a lot whose auction has started stays
+// in place until the auction is over, for everybody. Nothing releases the funds
+// held for the bids or the sale of a lot in the trash, so a lot whose auction
+// isn't over has to have no bids, and a sold lot has to be paid for or given up
+// on.
+func checkDeletableLot(lot *entity.Lot, top *entity.Bid, now time.Time) error {
+	if lot.Status != entity.LotFinished && lot.BiddingStarted(now) {
+		return &entity.PolicyError{Reason: "a lot can't be deleted once bidding on it has started, until the end of its auction"}
+	}
+
+	if lot.Status != entity.LotFinished && top != nil {
+		return &entity.PolicyError{Reason: "a lot with bids can't be deleted before the end of its auction"}
+	}
+
+	if lot.AwaitingPayment() || (lot.SettlementStatus != nil && *lot.SettlementStatus == entity.SettlementOffered) {
+		return &entity.PolicyError{Reason: "a sold lot can't be deleted while it is waiting for the payment"}
+	}
+
+	return nil
+}
+
+// checkFrozenLot checks that the update doesn't touch the terms of a lot whose
+// auction has started: the bidders bid on its prices, times and status as they
+// were, so these stay as they are for everybody, admins included.
+func checkFrozenLot(stored, lot *entity.Lot, now time.Time) error {
+	if !stored.BiddingStarted(now) {
+		return nil
+	}
+
+	var changed []string
+
+	check := func(field string, same bool) {
+		if !same {
+			changed = append(changed, field)
+		}
+	}
+
+	check("status", stored.Status == lot.Status)
+	check("type", stored.Type == lot.Type)
+	check("start_price", stored.StartPrice == lot.StartPrice)
+	check("step_price", stored.StepPrice == lot.StepPrice)
+	check("reserve_price", reflect.DeepEqual(stored.ReservePrice, lot.ReservePrice))
+	check("buy_now_price", reflect.DeepEqual(stored.BuyNowPrice, lot.BuyNowPrice))
+	check("start_at", stored.StartAt.Equal(lot.StartAt))
+	check("end_at", stored.EndAt.Equal(lot.EndAt))
+	check("soft_close", reflect.DeepEqual(stored.SoftClose, lot.SoftClose))
+	check("dutch", reflect.DeepEqual(stored.Dutch, lot.Dutch))
+
+	if len(changed) > 0 {
+		return &entity.PolicyError{
+			Reason: strings.Join(changed, ", ") + " can't be changed once bidding on the lot has started",
+		}
+	}
+
+	return nil
+}
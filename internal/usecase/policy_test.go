@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+)
+
+func TestCheckDeletableLot(t *testing.T) {
+	now := time.Date(2022, 9, 9, 12, 0, 0, 0, time.UTC)
+
+	settlement := func(s entity.SettlementStatus) *entity.SettlementStatus { return &s }
+
+	tests := []struct {
+		name    string
+		lot     entity.Lot
+		top     *entity.Bid
+		allowed bool
+	}{
+		{
+			name:    "pending lot",
+			lot:     entity.Lot{Status: entity.LotPending, StartAt: now.Add(time.Hour)},
+			allowed: true,
+		},
+		{
+			name: "pending lot past its start time",
+			lot:  entity.Lot{Status: entity.LotPending, StartAt: now.Add(-time.Minute)},
+		},
+		{
+			name: "running auction",
+			lot:  entity.Lot{Status: entity.LotPublished, StartAt: now.Add(-time.Hour)},
+		},
+		{
+			name:    "finished without a winner",
+			lot:     entity.Lot{Status: entity.LotFinished, StartAt: now.Add(-time.Hour)},
+			allowed: true,
+		},
+		{
+			name: "sold lot waiting for the payment",
+			lot:  entity.Lot{Status: entity.LotFinished, SettlementStatus: settlement(entity.SettlementPending)},
+			top:  &entity.Bid{Price: 200},
+		},
+		{
+			name: "sold lot offered to the next bidder",
+			lot:  entity.Lot{Status: entity.LotFinished, SettlementStatus: settlement(entity.SettlementOffered)},
+			top:  &entity.Bid{Price: 200},
+		},
+		{
+			name:    "sold lot paid for",
+			lot:     entity.Lot{Status: entity.LotFinished, SettlementStatus: settlement(entity.SettlementSettled)},
+			top:     &entity.Bid{Price: 200},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDeletableLot(&tt.lot, tt.top, now)
+
+			var policyErr *entity.PolicyError
+
+			switch {
+			case tt.allowed && err != nil:
+				t.Errorf("got %v, want the lot to be deletable", err)
+			case !tt.allowed && !errors.As(err, &policyErr):
+				t.Errorf("got %v, want a PolicyError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ElOtro/auction-go/internal/entity"
+	"github.com/ElOtro/auction-go/pkg/logger"
+)
+
+const (
+	_defaultSchedulerInterval = 5 * time.Second
+	_maxSettleRetryDelay      = time.Hour
+)
+
+// LotScheduler - background worker which moves lots through their lifecycle.
+// Pending lots are published once StartAt has passed, published lots are
+// finished once EndAt has passed, sold lots the winner hasn't paid for in time
+// are offered to the next bidder and the rest are settled; a settlement which
+// has failed is tried again less and less often. Lots kept in the trash for
+// longer than the retention period are purged. All the queries look for every
+// overdue lot, so lots missed while the application was down are caught up on
+// the first tick. A stage which fails is logged and the next ones run all the
+// same.
+type LotScheduler struct {
+	uc       *LotUseCase
+	l        logger.Interface
+	interval time.Duration
+	retries  map[int64]*settleRetry
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// settleRetry is the time a lot whose settlement has failed is tried again at,
+// the delay doubles with every failure.
+type settleRetry struct {
+	at    time.Time
+	delay time.Duration
+}
+
+// NewLotScheduler -.
+func NewLotScheduler(uc *LotUseCase, l logger.Interface, interval time.Duration) *LotScheduler {
+	if interval <= 0 {
+		interval = _defaultSchedulerInterval
+	}
+
+	return &LotScheduler{
+		uc:       uc,
+		l:        l,
+		interval: interval,
+		retries:  make(map[int64]*settleRetry),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start - running the scheduler loop in its own goroutine.
+func (s *LotScheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.tick(time.Now())
+
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop - stopping the scheduler and waiting for the current tick to complete.
+func (s *LotScheduler) Stop() {
+	close(s.quit)
+	<-s.done
+}
+
+func (s *LotScheduler) tick(now time.Time) {
+	ids, err := s.uc.Publish(now)
+	if err != nil {
+		s.l.Error(fmt.Errorf("usecase - LotScheduler - Publish: %w", err))
+	}
+
+	for _, id := range ids {
+		s.l.Info("usecase - LotScheduler - lot %d published", id)
+	}
+
+	ids, err = s.uc.Expired(now)
+	if err != nil {
+		s.l.Error(fmt.Errorf("usecase - LotScheduler - Expired: %w", err))
+	}
+
+	for _, id := range ids {
+		lot, err := s.uc.Finish(id)
+		if err != nil {
+			// The lot has already been closed by someone else.
+			if errors.Is(err, entity.ErrEditConflict) {
+				continue
+			}
+			s.l.Error(fmt.Errorf("usecase - LotScheduler - Finish lot %d: %w", id, err))
+			continue
+		}
+
+		s.l.Info("usecase - LotScheduler - lot %d finished, end price %d", lot.ID, lot.EndPrice)
+	}
+
+	ids, err = s.uc.Overdue(now)
+	if err != nil {
+		s.l.Error(fmt.Errorf("usecase - LotScheduler - Overdue: %w", err))
+	}
+
+	for _, id := range ids {
+		_, offer, err := s.uc.Reoffer(id)
+		if err != nil {
+			// The lot has already been paid for or offered by someone else.
+			if errors.Is(err, entity.ErrEditConflict) {
+				continue
+			}
+			s.l.Error(fmt.Errorf("usecase - LotScheduler - Reoffer lot %d: %w", id, err))
+			continue
+		}
+
+		if offer == nil {
+			s.l.Info("usecase - LotScheduler - lot %d unsold", id)
+			continue
+		}
+
+		s.l.Info("usecase - LotScheduler - lot %d offered to bidder %d at %d", id, *offer.BidderID, offer.Price)
+	}
+
+	// The failed settlements keep their backoff if the lots can't be fetched.
+	ids, err = s.uc.Unsettled()
+	if err != nil {
+		s.l.Error(fmt.Errorf("usecase - LotScheduler - Unsettled: %w", err))
+	} else {
+		s.settle(now, ids)
+	}
+
+	ids, err = s.uc.Purge(now)
+	if err != nil {
+		s.l.Error(fmt.Errorf("usecase - LotScheduler - Purge: %w", err))
+	}
+
+	for _, id := range ids {
+		s.l.Info("usecase - LotScheduler - lot %d purged", id)
+	}
+}
+
+// settle settles the sold lots. A lot whose settlement has failed is put off
+// for a while, the failure is only logged the first time.
+func (s *LotScheduler) settle(now time.Time, ids []int64) {
+	unsettled := make(map[int64]bool, len(ids))
+
+	for _, id := range ids {
+		unsettled[id] = true
+
+		retry, failed := s.retries[id]
+		if failed && now.Before(retry.at) {
+			continue
+		}
+
+		lot, err := s.uc.Settle(id)
+		if err != nil {
+			// The lot has already been settled by someone else.
+			if errors.Is(err, entity.ErrEditConflict) {
+				continue
+			}
+			s.l.Error(fmt.Errorf("usecase - LotScheduler - Settle lot %d: %w", id, err))
+			continue
+		}
+
+		if *lot.SettlementStatus != entity.SettlementFailed {
+			delete(s.retries, id)
+			s.l.Info("usecase - LotScheduler - lot %d settlement %s", lot.ID, *lot.SettlementStatus)
+			continue
+		}
+
+		if !failed {
+			retry = &settleRetry{delay: s.interval}
+			s.retries[id] = retry
+			s.l.Info("usecase - LotScheduler - lot %d settlement %s", lot.ID, *lot.SettlementStatus)
+		}
+
+		retry.delay *= 2
+		if retry.delay > _maxSettleRetryDelay {
+			retry.delay = _maxSettleRetryDelay
+		}
+		retry.at = now.Add(retry.delay)
+	}
+
+	// The lots which aren't waiting for the payment any more (offered to the
+	// next bidder, say) are forgotten.
+	for id := range s.retries {
+		if !unsettled[id] {
+			delete(s.retries, id)
+		}
+	}
+}
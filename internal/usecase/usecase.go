@@ -1,20 +1,31 @@
 package usecase
 
-import repo "github.com/ElOtro/auction-go/internal/infrastructure/repo/postgres"
+import (
+	"github.com/ElOtro/auction-go/config"
+	repo "github.com/ElOtro/auction-go/internal/infrastructure/repo/postgres"
+)
 
 // Create a UseCases struct which wraps all repos.
 type UseCases struct {
-	User UserUseCase
-	Lot  LotUseCase
-	Bid  BidUseCase
+	User       UserUseCase
+	Lot        LotUseCase
+	Bid        BidUseCase
+	Event      EventUseCase
+	Account    AccountUseCase
+	Category   CategoryUseCase
+	Attachment AttachmentUseCase
 }
 
 // For ease of use, we also add a NewUseCases() method which returns a UseCases struct containing
 // the initialized UseCases.
-func NewUseCases(repos *repo.Repo) UseCases {
+func NewUseCases(repos *repo.Repo, blobs BlobStorage, events EventPublisher, broker EventBroker, auction config.Auction, search config.Search, storage config.Storage, trash config.Trash) UseCases {
 	return UseCases{
-		User: *NewUserUseCase(&repos.Users),
-		Lot:  *NewLotUseCase(&repos.Lots),
-		Bid:  *NewBidUseCase(&repos.Bids, &repos.Lots),
+		User:       *NewUserUseCase(&repos.Users),
+		Lot:        *NewLotUseCase(&repos.Lots, &repos.Attachments, blobs, events, auction, search, trash),
+		Bid:        *NewBidUseCase(&repos.Bids, &repos.Lots),
+		Event:      *NewEventUseCase(broker, &repos.Lots),
+		Account:    *NewAccountUseCase(&repos.Accounts),
+		Category:   *NewCategoryUseCase(&repos.Categories),
+		Attachment: *NewAttachmentUseCase(&repos.Attachments, &repos.Lots, blobs, storage),
 	}
 }
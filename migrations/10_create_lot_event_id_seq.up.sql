@@ -0,0 +1,3 @@
+CREATE SEQUENCE IF NOT EXISTS lot_event_id_seq;
+
+comment on sequence lot_event_id_seq is 'Lot Event IDs, shared by all instances';
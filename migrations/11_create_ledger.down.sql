@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP FUNCTION IF EXISTS ledger_apply_entry;
+DROP FUNCTION IF EXISTS ledger_check_balanced;
+DROP FUNCTION IF EXISTS ledger_append_only;
+DELETE FROM accounts WHERE user_id IS NULL;
+DROP INDEX IF EXISTS accounts_user_id_index;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_balance_check;
+ALTER TABLE accounts RENAME COLUMN balance TO ammount;
\ No newline at end of file
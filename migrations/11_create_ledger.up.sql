@@ -0,0 +1,95 @@
+ALTER TABLE accounts RENAME COLUMN ammount TO balance;
+UPDATE accounts SET balance = 0 WHERE balance IS NULL;
+ALTER TABLE accounts ALTER COLUMN balance SET NOT NULL;
+ALTER TABLE accounts ADD CONSTRAINT accounts_balance_check CHECK (user_id IS NULL OR balance >= 0);
+
+INSERT INTO accounts (user_id)
+SELECT id FROM users WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE accounts.user_id = users.id);
+
+CREATE UNIQUE INDEX accounts_user_id_index ON accounts USING btree (user_id);
+
+-- The cash account stands for the money outside the system, deposits come from it
+-- and withdrawals go to it.
+INSERT INTO accounts (id, user_id) VALUES ('00000000-0000-0000-0000-000000000000', NULL);
+
+CREATE TABLE ledger_transactions (
+  id BIGSERIAL PRIMARY KEY,
+  kind text NOT NULL,
+  reference text NOT NULL DEFAULT '',
+  created_by bigint REFERENCES users (id),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE ledger_entries (
+  id BIGSERIAL PRIMARY KEY,
+  transaction_id bigint NOT NULL REFERENCES ledger_transactions (id),
+  account_id uuid NOT NULL REFERENCES accounts (id),
+  amount bigint NOT NULL CHECK (amount <> 0),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_entries_transaction_id_index ON ledger_entries USING btree (transaction_id);
+CREATE INDEX ledger_entries_account_id_index ON ledger_entries USING btree (account_id, id);
+
+-- Balances held before the ledger existed are brought in as opening entries.
+WITH opening AS (
+  INSERT INTO ledger_transactions (kind, reference)
+  SELECT 'opening', 'opening balance'
+  WHERE EXISTS (SELECT 1 FROM accounts WHERE balance <> 0)
+  RETURNING id
+)
+INSERT INTO ledger_entries (transaction_id, account_id, amount)
+SELECT opening.id, accounts.id, accounts.balance FROM opening, accounts WHERE accounts.balance <> 0
+UNION ALL
+SELECT opening.id, '00000000-0000-0000-0000-000000000000', -SUM(accounts.balance) FROM opening, accounts
+WHERE accounts.balance <> 0 GROUP BY opening.id;
+
+UPDATE accounts SET balance = (SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = accounts.id);
+
+-- The ledger is append-only: a mistake is corrected with a new transaction.
+CREATE FUNCTION ledger_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_transactions_append_only BEFORE UPDATE OR DELETE ON ledger_transactions
+FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+CREATE TRIGGER ledger_entries_append_only BEFORE UPDATE OR DELETE ON ledger_entries
+FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- Every transaction has to balance to zero, it is checked at commit when all of
+-- its entries are in.
+CREATE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT SUM(amount) FROM ledger_entries WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+    RAISE EXCEPTION 'ledger transaction % does not balance', NEW.transaction_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced AFTER INSERT ON ledger_entries
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+-- The balance of an account is the sum of its entries, it is kept up to date as
+-- the entries come in.
+CREATE FUNCTION ledger_apply_entry() RETURNS trigger AS $$
+BEGIN
+  UPDATE accounts SET balance = balance + NEW.amount, updated_at = NOW() WHERE id = NEW.account_id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_apply AFTER INSERT ON ledger_entries
+FOR EACH ROW EXECUTE FUNCTION ledger_apply_entry();
+
+comment on column accounts.balance is 'Balance (Sum Of Ledger Entries)';
+comment on column ledger_transactions.kind is 'Kind (deposit, withdrawal, ...)';
+comment on column ledger_transactions.reference is 'Reference';
+comment on column ledger_transactions.created_by is 'Created By (User)';
+comment on column ledger_entries.transaction_id is 'Ledger Transaction ID';
+comment on column ledger_entries.account_id is 'Account Number';
+comment on column ledger_entries.amount is 'Amount (Credit > 0, Debit < 0)';
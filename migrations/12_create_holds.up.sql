@@ -0,0 +1,15 @@
+CREATE TABLE holds (
+  id BIGSERIAL PRIMARY KEY,
+  account_id uuid NOT NULL REFERENCES accounts (id),
+  lot_id bigint NOT NULL REFERENCES lots (id) ON DELETE CASCADE,
+  amount bigint NOT NULL CHECK (amount > 0),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) without time zone NOT NULL DEFAULT NOW(),
+  UNIQUE (lot_id, account_id)
+);
+
+CREATE INDEX holds_account_id_index ON holds USING btree (account_id);
+
+comment on column holds.account_id is 'Account Number';
+comment on column holds.lot_id is 'Lot ID';
+comment on column holds.amount is 'Amount Held For The Bids On The Lot';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ledger_transactions_settlement_index;
+-- The platform account stays, the ledger entries written to it are never deleted.
+ALTER TABLE lots DROP COLUMN IF EXISTS settlement_status;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE lots ADD COLUMN settlement_status text;
+
+CREATE INDEX lots_settlement_status_index ON lots USING btree (settlement_status) WHERE settlement_status IS NOT NULL;
+
+-- The platform account collects the commission on the sold lots. It is kept
+-- when the migration is rolled back, it may be there already.
+INSERT INTO accounts (id, user_id) VALUES ('00000000-0000-0000-0000-000000000001', NULL)
+ON CONFLICT (id) DO NOTHING;
+
+-- A lot is settled once, whatever happens to the settlement job.
+CREATE UNIQUE INDEX ledger_transactions_settlement_index ON ledger_transactions USING btree (reference) WHERE kind = 'settlement';
+
+comment on column lots.settlement_status is 'Settlement Status (pending, settled, failed)';
@@ -0,0 +1,4 @@
+comment on column lots.settlement_status is 'Settlement Status (pending, settled, failed)';
+
+DROP TABLE IF EXISTS lot_history;
+DROP TABLE IF EXISTS offers;
\ No newline at end of file
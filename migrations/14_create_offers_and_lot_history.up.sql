@@ -0,0 +1,35 @@
+CREATE TABLE offers (
+  id BIGSERIAL PRIMARY KEY,
+  lot_id bigint NOT NULL REFERENCES lots (id) ON DELETE CASCADE,
+  bidder_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  price bigint NOT NULL CHECK (price > 0),
+  status text NOT NULL DEFAULT 'open',
+  expires_at timestamp(0) with time zone NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) without time zone NOT NULL DEFAULT NOW(),
+  UNIQUE (lot_id, bidder_id)
+);
+
+CREATE INDEX offers_expires_at_index ON offers USING btree (expires_at) WHERE status = 'open';
+
+CREATE TABLE lot_history (
+  id BIGSERIAL PRIMARY KEY,
+  lot_id bigint NOT NULL REFERENCES lots (id) ON DELETE CASCADE,
+  kind text NOT NULL,
+  user_id bigint REFERENCES users (id) ON DELETE SET NULL,
+  price bigint,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX lot_history_lot_id_index ON lot_history USING btree (lot_id);
+
+comment on column offers.lot_id is 'Lot ID';
+comment on column offers.bidder_id is 'Bidder ID (User)';
+comment on column offers.price is 'Price Of The Bid Of The Bidder';
+comment on column offers.status is 'Status (open, accepted, declined, expired)';
+comment on column offers.expires_at is 'Time The Offer Has To Be Accepted By';
+comment on column lot_history.lot_id is 'Lot ID';
+comment on column lot_history.kind is 'What Has Happened (sold, paid, payment_failed, payment_overdue, offered, offer_accepted, offer_declined, offer_expired, unsold)';
+comment on column lot_history.user_id is 'User ID';
+comment on column lot_history.price is 'Price';
+comment on column lots.settlement_status is 'Settlement Status (pending, settled, failed, offered, defaulted)';
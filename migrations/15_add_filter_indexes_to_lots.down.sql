@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS lots_start_price_index;
+DROP INDEX IF EXISTS lots_creator_id_index;
\ No newline at end of file
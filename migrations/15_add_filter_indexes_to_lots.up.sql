@@ -0,0 +1,2 @@
+CREATE INDEX lots_creator_id_index ON lots USING btree (creator_id);
+CREATE INDEX lots_start_price_index ON lots USING btree (start_price);
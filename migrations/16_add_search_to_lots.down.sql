@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS lots_search_index;
+ALTER TABLE lots DROP COLUMN IF EXISTS search;
+ALTER TABLE lots DROP COLUMN IF EXISTS search_language;
\ No newline at end of file
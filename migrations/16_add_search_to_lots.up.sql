@@ -0,0 +1,14 @@
+-- Every lot is indexed in its own text search configuration, the search
+-- language of the application (search.language in the config) when it was
+-- created. The search queries are read in the configuration of the lot.
+ALTER TABLE lots ADD COLUMN search_language regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE lots ADD COLUMN search tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector(search_language, COALESCE(title, '')), 'A') ||
+  setweight(to_tsvector(search_language, COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX lots_search_index ON lots USING gin (search);
+
+comment on column lots.search_language is 'Text Search Configuration Of The Lot';
+comment on column lots.search is 'Full-Text Search Document (Title And Description)';
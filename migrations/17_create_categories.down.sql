@@ -0,0 +1,4 @@
+ALTER TABLE lots DROP COLUMN IF EXISTS tags;
+ALTER TABLE lots DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS categories;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE categories (
+  id BIGSERIAL PRIMARY KEY,
+  parent_id bigint REFERENCES categories (id) ON DELETE RESTRICT,
+  name text NOT NULL,
+  slug text NOT NULL UNIQUE,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX categories_parent_id_index ON categories USING btree (parent_id);
+
+ALTER TABLE lots ADD COLUMN category_id bigint REFERENCES categories (id) ON DELETE SET NULL;
+ALTER TABLE lots ADD COLUMN tags text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX lots_category_id_index ON lots USING btree (category_id);
+CREATE INDEX lots_tags_index ON lots USING gin (tags);
+
+comment on column categories.parent_id is 'Parent Category ID';
+comment on column categories.name is 'Name';
+comment on column categories.slug is 'Name In URLs';
+comment on column lots.category_id is 'Category ID';
+comment on column lots.tags is 'Tags';
@@ -0,0 +1,19 @@
+CREATE TABLE attachments (
+  id BIGSERIAL PRIMARY KEY,
+  lot_id bigint NOT NULL REFERENCES lots (id) ON DELETE CASCADE,
+  filename text NOT NULL,
+  content_type text NOT NULL,
+  size bigint NOT NULL CHECK (size > 0),
+  storage_key text NOT NULL UNIQUE,
+  thumbnail_key text UNIQUE,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX attachments_lot_id_index ON attachments USING btree (lot_id);
+
+comment on column attachments.lot_id is 'Lot ID';
+comment on column attachments.filename is 'Name Of The Uploaded File';
+comment on column attachments.content_type is 'Content Type';
+comment on column attachments.size is 'Size In Bytes';
+comment on column attachments.storage_key is 'Key Of The File In The Storage';
+comment on column attachments.thumbnail_key is 'Key Of The Thumbnail In The Storage (Images)';
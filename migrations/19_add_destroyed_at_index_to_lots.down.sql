@@ -0,0 +1 @@
+DROP INDEX IF EXISTS lots_destroyed_at_index;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX lots_destroyed_at_index ON lots USING btree (destroyed_at) WHERE destroyed_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE lots ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+comment on column lots.version is 'Version, Goes Up With Every Change Of The Lot';
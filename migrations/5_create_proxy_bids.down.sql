@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS proxy_bids CASCADE;
+DROP SEQUENCE IF EXISTS proxy_bids_set_seq;
+ALTER TABLE bids DROP COLUMN IF EXISTS auto;
\ No newline at end of file
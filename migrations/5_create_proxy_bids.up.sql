@@ -0,0 +1,25 @@
+-- updated_at only has a resolution of one second, the proxies set within the
+-- same second would be ranked by id. The sequence orders them by when their
+-- maximum was set.
+CREATE SEQUENCE proxy_bids_set_seq;
+
+CREATE TABLE proxy_bids (
+  id BIGSERIAL PRIMARY KEY,
+  max_price bigint NOT NULL,
+  lot_id bigint REFERENCES lots (id) ON DELETE CASCADE,
+  bidder_id bigint REFERENCES users (id) ON DELETE CASCADE,
+  set_seq bigint NOT NULL DEFAULT nextval('proxy_bids_set_seq'),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) without time zone NOT NULL DEFAULT NOW(),
+  UNIQUE (lot_id, bidder_id)
+);
+
+ALTER SEQUENCE proxy_bids_set_seq OWNED BY proxy_bids.set_seq;
+
+ALTER TABLE bids ADD COLUMN auto bool DEFAULT false;
+
+comment on column proxy_bids.max_price is 'Maximum Price To Bid Up To';
+comment on column proxy_bids.lot_id is 'Lot ID';
+comment on column proxy_bids.bidder_id is 'Bidder ID (User)';
+comment on column proxy_bids.set_seq is 'Order In Which The Maximums Were Set';
+comment on column bids.auto is 'Placed By Proxy Bid';
@@ -0,0 +1 @@
+ALTER TABLE lots DROP COLUMN IF EXISTS reserve_price;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE lots ADD COLUMN reserve_price bigint;
+
+comment on column lots.reserve_price is 'Reserve Price (Hidden)';
@@ -0,0 +1 @@
+ALTER TABLE lots DROP COLUMN IF EXISTS buy_now_price;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE lots ADD COLUMN buy_now_price bigint;
+
+comment on column lots.buy_now_price is 'Buy Now Price';
@@ -0,0 +1,2 @@
+ALTER TABLE lots DROP COLUMN IF EXISTS soft_close_window;
+ALTER TABLE lots DROP COLUMN IF EXISTS soft_close_extension;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE lots ADD COLUMN soft_close_window integer;
+ALTER TABLE lots ADD COLUMN soft_close_extension integer;
+
+comment on column lots.soft_close_window is 'Soft Close Window (Minutes Before End)';
+comment on column lots.soft_close_extension is 'Soft Close Extension (Minutes)';
@@ -0,0 +1,3 @@
+ALTER TABLE lots DROP COLUMN IF EXISTS type;
+ALTER TABLE lots DROP COLUMN IF EXISTS floor_price;
+ALTER TABLE lots DROP COLUMN IF EXISTS drop_interval;
\ No newline at end of file
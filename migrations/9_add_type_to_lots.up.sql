@@ -0,0 +1,7 @@
+ALTER TABLE lots ADD COLUMN type text NOT NULL DEFAULT 'english';
+ALTER TABLE lots ADD COLUMN floor_price bigint;
+ALTER TABLE lots ADD COLUMN drop_interval integer;
+
+comment on column lots.type is 'Auction Type (english, dutch)';
+comment on column lots.floor_price is 'Floor Price (Dutch)';
+comment on column lots.drop_interval is 'Price Drop Interval In Minutes (Dutch)';
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	_defaultMinBackoff = 500 * time.Millisecond
+	_defaultMaxBackoff = 30 * time.Second
+)
+
+// Listener - LISTEN on a channel over a dedicated connection, the pool can't be
+// used for it as the notifications come to the connection which has run
+// LISTEN. When the connection drops it is opened again with an exponential
+// backoff; the notifications sent while it was down are lost.
+type Listener struct {
+	url     string
+	channel string
+	handler func(payload string)
+	onError func(err error)
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ListenerOption -.
+type ListenerOption func(*Listener)
+
+// Backoff -.
+func Backoff(min, max time.Duration) ListenerOption {
+	return func(l *Listener) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+// OnError - reporting the errors which make the listener reconnect.
+func OnError(fn func(err error)) ListenerOption {
+	return func(l *Listener) {
+		l.onError = fn
+	}
+}
+
+// NewListener -.
+func NewListener(url, channel string, handler func(payload string), opts ...ListenerOption) *Listener {
+	l := &Listener{
+		url:        url,
+		channel:    channel,
+		handler:    handler,
+		onError:    func(error) {},
+		minBackoff: _defaultMinBackoff,
+		maxBackoff: _defaultMaxBackoff,
+		done:       make(chan struct{}),
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Start - listening in its own goroutine, handler is called with the payload of
+// every notification.
+func (l *Listener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	go func() {
+		defer close(l.done)
+
+		backoff := l.minBackoff
+
+		for {
+			listening, err := l.listen(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			l.onError(err)
+
+			// The connection was fine for a while, it's a new problem.
+			if listening {
+				backoff = l.minBackoff
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > l.maxBackoff {
+				backoff = l.maxBackoff
+			}
+		}
+	}()
+}
+
+// Stop - closing the connection and waiting for the listener to return.
+func (l *Listener) Stop() {
+	l.cancel()
+	<-l.done
+}
+
+// listen connects, runs LISTEN and waits for the notifications until the
+// connection fails. It reports whether LISTEN has succeeded.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.url)
+	if err != nil {
+		return false, fmt.Errorf("postgres - Listener - pgx.Connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize())
+	if err != nil {
+		return false, fmt.Errorf("postgres - Listener - LISTEN: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("postgres - Listener - WaitForNotification: %w", err)
+		}
+
+		l.handler(notification.Payload)
+	}
+}
@@ -0,0 +1,64 @@
+// Package thumbnail implements downscaling of images.
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// New returns a copy of src scaled down to fit a size x size square, keeping the
+// aspect ratio. Every pixel of the thumbnail is the average of the pixels of src
+// it covers. An image which fits already is only copied.
+func New(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, maxInt(1, h*size/w)
+		} else {
+			tw, th = maxInt(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+
+	for y := 0; y < th; y++ {
+		y0 := b.Min.Y + y*h/th
+		y1 := maxInt(y0+1, b.Min.Y+(y+1)*h/th)
+
+		for x := 0; x < tw; x++ {
+			x0 := b.Min.X + x*w/tw
+			x1 := maxInt(x0+1, b.Min.X+(x+1)*w/tw)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}